@@ -0,0 +1,807 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ary82/goseek/internal/answer"
+	"github.com/ary82/goseek/internal/chunk"
+	"github.com/ary82/goseek/internal/constants"
+	"github.com/ary82/goseek/internal/conversation"
+	"github.com/ary82/goseek/internal/embed"
+	"github.com/ary82/goseek/internal/llm"
+	"github.com/ary82/goseek/internal/mode"
+	"github.com/ary82/goseek/internal/prompt"
+	"github.com/ary82/goseek/internal/retrieval"
+	"github.com/ary82/goseek/internal/scrape"
+	"github.com/ary82/goseek/internal/search"
+	"github.com/ary82/goseek/internal/vectorstorage"
+	"github.com/google/uuid"
+	"github.com/pinecone-io/go-pinecone/v3/pinecone"
+)
+
+const (
+	// upsertBatchSize is the number of records sent per upsert call
+	upsertBatchSize = 80
+	// enoughSources is the number of scraped pages after which the remaining
+	// search results are dropped instead of waited on, unless the mode
+	// gathers more
+	enoughSources = 6
+	// dedupThreshold is the estimated similarity above which chunks from
+	// different pages are collapsed into one
+	dedupThreshold = 0.8
+	// maxPassages bounds the chunks considered for the LLM context unless
+	// the mode sets it; the context token budget decides how many fit
+	maxPassages = 12
+	// defaultContextTokens is the token budget for retrieved text in the
+	// prompt, well within the model's context window
+	defaultContextTokens = 4000
+	// rerankDepth is the number of hits retrieved and reranked to pick
+	// maxPassages
+	rerankDepth = 30
+	// historyTurns is the number of earlier turns of a conversation given
+	// to the prompt and used to condense follow-ups
+	historyTurns = 4
+	// childMaxsize and childMinsize size the child chunks that are searched
+	// in place of their parents
+	childMaxsize = 128
+	childMinsize = 16
+)
+
+// chunkWorkers bounds how many pages are chunked at once
+var chunkWorkers = runtime.GOMAXPROCS(0)
+
+// Pipeline orchestrator
+type GoSeekPipeline struct {
+	search  search.SearchEngine
+	scraper scrape.Scraper
+	chunker chunk.Chunker
+	// children splits chunks into the smaller ones that are searched
+	children chunk.Chunker
+	strategy retrieval.Strategy
+	// reranker reorders the retrieved hits; nil keeps the retrieval order
+	reranker retrieval.Reranker
+	// mmr picks diverse hits across sources
+	mmr *retrieval.MMR
+	// engine names the search engine, stored with every record
+	engine string
+	// context packs the retrieved chunks into the prompt's token budget
+	context *retrieval.ContextBuilder
+	vector  vectorstorage.VectorStore
+	llm     llm.LLM
+	// prompts holds the answer prompt templates
+	prompts *prompt.Registry
+	// verifier checks the answer against its sources; nil skips the check
+	verifier answer.Verifier
+	mu       sync.RWMutex
+	cache    map[string]*Answer
+
+	scrapeFailures *scrape.FailureStats
+
+	// condenser turns follow-ups into standalone queries
+	condenser conversation.Condenser
+	// reuseChunks makes the queries of a conversation share their chunks
+	reuseChunks bool
+}
+
+// QueryOptions tell who asks a query and how they want it answered
+type QueryOptions struct {
+	// User selects the user's prompt templates, see prompt.Registry.Select
+	User string
+	// Mode is the name of the answer mode, see mode.Modes; empty is
+	// mode.Default
+	Mode string
+	// Locale is the user's locale, e.g. "en-US", or empty when unknown
+	Locale string
+	// History is the conversation before the query, given to the prompt
+	History []prompt.Turn
+	// Namespace and Keywords hold the chunks of earlier queries, which the
+	// query adds to and retrieves from; empty uses new ones
+	Namespace string
+	Keywords  *retrieval.BM25Index
+}
+
+// Answer is the result of a query with its citations, along with the
+// sources that could not be used
+type Answer struct {
+	answer.Answer
+	// Format is the shape of the answer in the query's mode
+	Format mode.Format
+	Failed []scrape.ScrapedContent
+}
+
+func NewGoSeekPipeline() (*GoSeekPipeline, error) {
+	se, err := search.NewGoogleSearchEngine(constants.SEARCH_API, os.Getenv("SEARCH_API_KEY"), os.Getenv("SEARCH_CX"))
+	if err != nil {
+		return nil, err
+	}
+
+	scrapeOpts := []scrape.Option{
+		scrape.WithRetryPolicy(scrape.DefaultRetryPolicy),
+		scrape.WithFallback(os.Getenv("SCRAPE_FALLBACK_URL")),
+	}
+	if depth, _ := strconv.Atoi(os.Getenv("CRAWL_DEPTH")); depth > 0 {
+		v := os.Getenv("CRAWL_PAGES")
+		pages, err := strconv.Atoi(v)
+		if v != "" && (err != nil || pages <= 0) {
+			log.Printf("CRAWL_PAGES %q is not a positive number, following at most %d pages", v, scrape.DefaultCrawlPages)
+		}
+		scrapeOpts = append(scrapeOpts, scrape.WithCrawl(scrape.CrawlConfig{
+			MaxDepth:  depth,
+			MaxPages:  pages,
+			HostDelay: time.Second,
+		}))
+	}
+	if endpoint := os.Getenv("RENDERER_URL"); endpoint != "" {
+		scrapeOpts = append(scrapeOpts, scrape.WithRenderer(scrape.NewHTTPRenderer(&http.Client{}, endpoint)))
+	}
+	sc := scrape.NewWebScraper(&http.Client{}, constants.UA, 4, scrapeOpts...)
+	var tok chunk.Tokenizer
+	if vocab := os.Getenv("TOKENIZER_VOCAB"); vocab != "" {
+		tok, err = chunk.NewBPETokenizer(vocab)
+		if err != nil {
+			return nil, err
+		}
+	}
+	embedder, err := embed.NewHashEmbedder(256)
+	if err != nil {
+		return nil, err
+	}
+	ch, err := chunk.NewProfileRegistry(os.Getenv("CHUNK_PROFILES"), tok, embedder)
+	if err != nil {
+		return nil, err
+	}
+
+	strategy, err := retrieval.ParseStrategy(os.Getenv("RETRIEVAL_STRATEGY"))
+	if err != nil {
+		return nil, err
+	}
+
+	genllm, err := llm.NewGeminiLLM(context.Background(), os.Getenv("SEARCH_API_KEY"))
+	if err != nil {
+		return nil, err
+	}
+
+	var reranker retrieval.Reranker
+	switch name := os.Getenv("RERANKER"); name {
+	case "", "lexical":
+		reranker = retrieval.NewLexicalReranker()
+	case "llm":
+		reranker = retrieval.NewLLMReranker(genllm)
+	case "none":
+	default:
+		return nil, fmt.Errorf("unknown reranker %q", name)
+	}
+
+	prompts, err := prompt.NewRegistry(os.Getenv("PROMPT_DIR"))
+	if err != nil {
+		return nil, err
+	}
+
+	var verifier answer.Verifier
+	switch name := os.Getenv("GROUNDING"); name {
+	case "", "lexical":
+		verifier = answer.NewLexicalVerifier(envFloat("GROUNDING_THRESHOLD", answer.DefaultSupportThreshold))
+	case "llm":
+		verifier = answer.NewLLMVerifier(genllm)
+	case "none":
+	default:
+		return nil, fmt.Errorf("unknown grounding check %q", name)
+	}
+
+	mmr := retrieval.NewMMR(embedder, envFloat("MMR_LAMBDA", 0.7), int(envFloat("SOURCE_CAP", 2)))
+
+	var db vectorstorage.VectorStore
+	switch store := os.Getenv("VECTOR_STORE"); store {
+	case "", "pinecone":
+		db, err = vectorstorage.NewPineconeStorage(os.Getenv("PINECONE_API_KEY"), os.Getenv("PINECONE_HOST"))
+		if err != nil {
+			return nil, err
+		}
+	case "memory":
+		db = vectorstorage.NewMemoryStorage(embedder)
+	default:
+		return nil, fmt.Errorf("unknown vector store %q", store)
+	}
+
+	return &GoSeekPipeline{
+		search:   se,
+		scraper:  sc,
+		chunker:  ch,
+		children: chunk.NewTextChunker(childMaxsize, childMinsize, 0.1, tok),
+		strategy: strategy,
+		reranker: reranker,
+		mmr:      mmr,
+		engine:   vectorstorage.EngineGoogle,
+		context:  retrieval.NewContextBuilder(int(envFloat("CONTEXT_TOKENS", defaultContextTokens)), tok),
+		vector:   db,
+		llm:      genllm,
+		prompts:  prompts,
+		verifier: verifier,
+		cache:    make(map[string]*Answer),
+
+		scrapeFailures: scrape.NewFailureStats(),
+
+		condenser:   conversation.NewLLMCondenser(genllm),
+		reuseChunks: os.Getenv("CONVERSATION_REUSE_CHUNKS") == "true",
+	}, nil
+}
+
+func (p *GoSeekPipeline) ProcessQuery(ctx context.Context, query string, opts QueryOptions) (*Answer, error) {
+	m, err := mode.Parse(opts.Mode)
+	if err != nil {
+		return nil, err
+	}
+
+	// The same query asked in another mode or template is answered anew,
+	// and so is one that depends on a conversation
+	tmpl := p.prompts.Select(m.Template, opts.User)
+	cacheKey := strings.Join([]string{m.Name, tmpl, opts.Locale, query}, "\x00")
+	cacheable := len(opts.History) == 0 && opts.Namespace == ""
+
+	// Check cache first
+	p.mu.RLock()
+	if cached, exists := p.cache[cacheKey]; exists && cacheable {
+		p.mu.RUnlock()
+		return cached, nil
+	}
+	p.mu.RUnlock()
+
+	// Operators such as site: and last:year filter what is retrieved
+	text, filter, err := vectorstorage.ParseFilter(query, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(text) == "" {
+		return nil, fmt.Errorf("query has no search terms")
+	}
+
+	// Step 1: Search
+	searchQuery := text
+	for _, d := range filter.Domains {
+		searchQuery += " site:" + d
+	}
+	searchResults, err := p.search.Search(ctx, searchQuery, search.QueryParams{})
+	if err != nil {
+		return nil, fmt.Errorf("search failed: %w", err)
+	}
+
+	if len(searchResults.Items) == 0 {
+		return &Answer{Answer: answer.Answer{Text: "No search results found for your query."}, Format: mode.FormatText}, nil
+	}
+
+	// Step 2: Extract URLs and scrape
+	var toBeScraped []string
+	for _, v := range searchResults.Items {
+		toBeScraped = append(toBeScraped, v.Link)
+	}
+
+	// Steps 3 and 4: Chunk and store each page as soon as it is scraped
+	ns, keywords := opts.Namespace, opts.Keywords
+	if ns == "" || keywords == nil {
+		ns, keywords = uuid.NewString(), retrieval.NewBM25Index()
+	}
+	scraped, failed, err := p.ingest(ctx, text, toBeScraped, ns, keywords, cmp.Or(m.Sources, enoughSources))
+	if err != nil {
+		return nil, err
+	}
+
+	// Chunks of earlier queries may still answer a follow-up
+	if scraped == 0 && opts.Namespace == "" {
+		return &Answer{
+			Answer: answer.Answer{Text: "Could not scrape any content from the search results."},
+			Format: mode.FormatText,
+			Failed: failed,
+		}, nil
+	}
+
+	time.Sleep(3 * time.Second)
+
+	// Step 5: Retrieve relevant chunks
+	passages, err := p.retrieve(ctx, text, ns, keywords, filter, m)
+	if err != nil {
+		return nil, err
+	}
+
+	// Step 6: Generate response with LLM
+	builder := *p.context
+	if m.ContextScale > 0 {
+		builder.Budget = int(float64(builder.Budget) * m.ContextScale)
+	}
+	sources, ctxForLLM := builder.Build(passages)
+
+	llmPrompt, err := p.prompts.Render(tmpl, prompt.Data{
+		Query:   text,
+		Sources: sources,
+		Context: ctxForLLM,
+		Date:    time.Now(),
+		Locale:  opts.Locale,
+		History: opts.History,
+	})
+	if err != nil {
+		return nil, err
+	}
+	response, err := p.llm.GenerateContent(ctx, llmPrompt)
+	if err != nil {
+		return nil, fmt.Errorf("LLM generation failed: %w", err)
+	}
+
+	// Citations come from the numbered sources, not from the model
+	result := &Answer{
+		Answer: answer.FromResponse(*response, sources),
+		Format: m.Format,
+		Failed: failed,
+	}
+
+	// Step 7: Flag the claims the sources do not support
+	if p.verifier != nil {
+		verified, err := p.verifier.Verify(ctx, result.Answer, sources)
+		if err != nil {
+			log.Printf("grounding check failed: %v", err)
+		}
+		// The LLM verifier still returns the lexical verdicts when it fails
+		if err == nil || len(verified.Claims) > 0 {
+			result.Answer = verified
+			log.Printf("grounding check found %v of %v claims unsupported", len(verified.Unsupported()), len(verified.Claims))
+		}
+	}
+
+	// Cache the result
+	if cacheable {
+		p.mu.Lock()
+		p.cache[cacheKey] = result
+		p.mu.Unlock()
+	}
+
+	return result, nil
+}
+
+// NewConversation starts a conversation, which shares its chunks across
+// queries if the pipeline is configured to
+func (p *GoSeekPipeline) NewConversation(id string) *conversation.Conversation {
+	return conversation.New(id, p.reuseChunks)
+}
+
+// Ask answers a query in a conversation. A follow-up is condensed into a
+// standalone query with the latest turns, which are also given to the
+// prompt, and the turn is added to the conversation. It returns the
+// answer and the query that was searched.
+func (p *GoSeekPipeline) Ask(ctx context.Context, conv *conversation.Conversation, query string, opts QueryOptions) (*Answer, string, error) {
+	turns := conv.Last(historyTurns)
+	standalone := query
+	if len(turns) > 0 {
+		condensed, err := p.condenser.Condense(ctx, turns, query)
+		if err != nil {
+			log.Printf("condensing failed, searching the question as asked: %v", err)
+		} else {
+			standalone = condensed
+		}
+		opts.History = conversation.History(turns)
+	}
+	opts.Namespace, opts.Keywords = conv.Chunks()
+
+	result, err := p.ProcessQuery(ctx, standalone, opts)
+	if err != nil {
+		return nil, standalone, err
+	}
+	conv.Add(conversation.Turn{
+		Query:      query,
+		Standalone: standalone,
+		Answer:     result.Text,
+		Mode:       opts.Mode,
+	})
+	return result, standalone, nil
+}
+
+// ingest scrapes (and crawls from) urls and chunks and upserts each page into ns as it
+// arrives, chunking up to chunkWorkers pages at once. Chunks are stored in
+// parentNamespace(ns) and split into the children searched in ns and
+// keywords. Near-duplicate chunks across pages are stored once with all
+// their source links. Scraping stops early once enough pages have been
+// scraped.
+// It returns the number of pages scraped and the failures.
+func (p *GoSeekPipeline) ingest(ctx context.Context, query string, urls []string, ns string, keywords *retrieval.BM25Index, enough int) (int, []scrape.ScrapedContent, error) {
+	scrapeCtx, stopScraping := context.WithCancel(ctx)
+	defer stopScraping()
+
+	// Upserts run in the background so chunking never waits on the store
+	type upsertBatch struct {
+		ns      string
+		records []*pinecone.IntegratedRecord
+	}
+	batches := make(chan upsertBatch, 4)
+	upsertDone := make(chan struct{})
+	go func() {
+		defer close(upsertDone)
+		for batch := range batches {
+			if err := p.vector.UpsertRecords(ctx, batch.records, batch.ns); err != nil {
+				log.Printf("upsert failed: %v", err)
+			}
+		}
+	}()
+
+	// Small child chunks are searched in ns, their parents are stored
+	// alongside for the LLM
+	pending := make(map[string][]*pinecone.IntegratedRecord)
+	metaByLink := make(map[string]map[string]any)
+	upsert := func(ns string, v chunk.Chunk) {
+		pending[ns] = append(pending[ns], chunkRecord(v, metaByLink[v.Link]))
+		if len(pending[ns]) == upsertBatchSize {
+			batches <- upsertBatch{ns: ns, records: pending[ns]}
+			pending[ns] = nil
+		}
+	}
+	upsertWithChildren := func(parent chunk.Chunk) error {
+		children, err := chunk.Children(ctx, p.children, []chunk.Chunk{parent})
+		if err != nil {
+			return err
+		}
+		upsert(parentNamespace(ns), parent)
+		for _, child := range children {
+			upsert(ns, child)
+			keywords.Add(chunkHit(child, metaByLink[child.Link]))
+		}
+		return nil
+	}
+
+	// The feeder turns scraped pages into documents and stops scraping once
+	// enough have been sent; failed is only read after it has finished
+	var failed []scrape.ScrapedContent
+	docs := make(chan chunk.Document)
+	go func() {
+		defer close(docs)
+		sent, cutoff := 0, false
+		for result := range p.scraper.Crawl(scrapeCtx, query, urls) {
+			if result.Error != nil {
+				// Pages cut off by the early stop are not failures
+				if cutoff && errors.Is(result.Error, context.Canceled) {
+					continue
+				}
+				p.scrapeFailures.Record(result)
+				log.Printf("scrape failed: %v", result.Error)
+				failed = append(failed, result)
+				continue
+			}
+			if result.Content == "" {
+				continue
+			}
+
+			// Prefer the page markup so chunks follow its headings
+			content := result.Markup
+			if content == "" {
+				content = result.Content
+			}
+			engine := p.engine
+			if result.Depth > 0 {
+				engine = vectorstorage.EngineCrawl
+			}
+			docs <- chunk.Document{
+				Link:    result.URL,
+				Title:   result.Title,
+				Content: content,
+				Parts:   postParts(result.Posts),
+				Meta:    vectorstorage.RecordMeta(result.URL, result.Published, result.Lang, chunk.ContentTypeOf(result.URL, content), engine),
+			}
+			sent++
+
+			if sent >= enough && !cutoff {
+				log.Printf("scraped %v sources, skipping the rest", sent)
+				cutoff = true
+				stopScraping()
+			}
+		}
+	}()
+
+	var (
+		scraped  int
+		chunkErr error
+		dedup    = chunk.NewDeduper(dedupThreshold)
+		merged   = make(map[string]chunk.Chunk)
+	)
+	// Chunks are deduplicated in document order, so the survivors don't depend
+	// on which worker finishes first
+	for res := range chunk.InOrder(chunk.ChunkStream(ctx, p.chunker, docs, chunkWorkers)) {
+		if chunkErr != nil {
+			continue
+		}
+		if res.Err != nil {
+			chunkErr = res.Err
+			stopScraping()
+			continue
+		}
+		scraped++
+		metaByLink[res.Doc.Link] = res.Doc.Meta
+
+		for _, v := range res.Chunks {
+			survivor, isNew := dedup.Add(v)
+			if !isNew {
+				// The survivor may already be upserted, so it is sent again
+				// with its new sources at the end
+				merged[survivor.ID] = survivor
+				continue
+			}
+			if err := upsertWithChildren(survivor); err != nil {
+				chunkErr = err
+				stopScraping()
+				break
+			}
+		}
+	}
+	if len(merged) > 0 {
+		log.Printf("collapsed near-duplicates into %v chunks", len(merged))
+	}
+	for _, v := range merged {
+		if chunkErr != nil {
+			break
+		}
+		chunkErr = upsertWithChildren(v)
+	}
+	for target, records := range pending {
+		if len(records) > 0 {
+			batches <- upsertBatch{ns: target, records: records}
+		}
+	}
+	close(batches)
+	<-upsertDone
+
+	if chunkErr != nil {
+		return 0, nil, chunkErr
+	}
+	if err := ctx.Err(); err != nil {
+		return 0, nil, fmt.Errorf("scraping failed: %w", err)
+	}
+
+	sort.Slice(failed, func(i, j int) bool {
+		return failed[i].URL < failed[j].URL
+	})
+	return scraped, failed, nil
+}
+
+// envFloat reads a number from the environment, or def when unset or invalid
+func envFloat(key string, def float64) float64 {
+	v, err := strconv.ParseFloat(os.Getenv(key), 64)
+	if err != nil {
+		return def
+	}
+	return v
+}
+
+// retrieve searches the child chunks of a query that match filter with
+// p.strategy, weighted for the mode m, and returns the parents of the best
+// passages of them, best first, falling back to a child when its parent is
+// missing
+func (p *GoSeekPipeline) retrieve(ctx context.Context, query string, ns string, keywords *retrieval.BM25Index, filter vectorstorage.Filter, m mode.Mode) ([]retrieval.Hit, error) {
+	passages := cmp.Or(m.Passages, maxPassages)
+
+	// Over-fetch for reranking, and since several children may share a
+	// parent and weighting can promote e.g. accepted answers
+	n := max(rerankDepth, passages)
+
+	var dense, sparse []retrieval.Hit
+	if p.strategy != retrieval.StrategySparse {
+		relevantRecords, err := p.vector.SearchTopK(ctx, query, n, ns, filter)
+		if err != nil {
+			log.Println(err)
+			return nil, fmt.Errorf("vector search failed: %w", err)
+		}
+		rr, ok := relevantRecords.(*pinecone.SearchRecordsResponse)
+		if !ok {
+			return nil, fmt.Errorf("vector search result corrupted")
+		}
+		dense = denseHits(rr.Result.Hits)
+	}
+	if p.strategy != retrieval.StrategyDense {
+		for _, h := range keywords.Search(query, keywords.Len()) {
+			if len(sparse) == n {
+				break
+			}
+			if filter.Match(h.Meta) {
+				sparse = append(sparse, h)
+			}
+		}
+	}
+
+	var ranked []retrieval.Hit
+	switch p.strategy {
+	case retrieval.StrategyDense:
+		ranked = dense
+	case retrieval.StrategySparse:
+		ranked = sparse
+	default:
+		ranked = retrieval.FuseRRF(dense, sparse)
+	}
+	log.Printf("%s retrieval found %v dense and %v keyword hits", p.strategy, len(dense), len(sparse))
+
+	// The mode may favour e.g. code and official documentation
+	for i := range ranked {
+		if ranked[i].Weight <= 0 {
+			ranked[i].Weight = 1
+		}
+		ranked[i].Weight *= m.Boost(ranked[i])
+	}
+	ranked = retrieval.ApplyWeights(ranked)
+	ranked = ranked[:min(n, len(ranked))]
+	if p.reranker != nil {
+		reranked, err := p.reranker.Rerank(ctx, query, ranked)
+		if err != nil {
+			log.Printf("rerank failed, keeping retrieval order: %v", err)
+		} else {
+			ranked = retrieval.ApplyWeights(reranked)
+		}
+	}
+
+	// Keep the best child of every parent, then pick passages of them
+	var (
+		candidates []retrieval.Hit
+		seen       = make(map[string]bool)
+	)
+	for _, h := range ranked {
+		key := h.ParentID
+		if key == "" {
+			key = h.ID
+		}
+		if !seen[key] {
+			seen[key] = true
+			candidates = append(candidates, h)
+		}
+	}
+	hits, err := p.mmr.Select(ctx, candidates, passages)
+	if err != nil {
+		log.Printf("mmr failed, keeping ranked order: %v", err)
+		hits = candidates[:min(passages, len(candidates))]
+	}
+
+	var parentIDs []string
+	for _, h := range hits {
+		if h.ParentID != "" {
+			parentIDs = append(parentIDs, h.ParentID)
+		}
+	}
+
+	parents := make(map[string]map[string]any)
+	if len(parentIDs) > 0 {
+		fetched, err := p.vector.FetchRecords(ctx, parentIDs, parentNamespace(ns))
+		if err != nil {
+			log.Printf("fetching parent chunks failed, using matched chunks: %v", err)
+		} else if fr, ok := fetched.(*pinecone.FetchVectorsResponse); ok {
+			for id, v := range fr.Vectors {
+				if v != nil && v.Metadata != nil {
+					parents[id] = v.Metadata.AsMap()
+				}
+			}
+		}
+	}
+
+	// Hand the LLM the parents of the matched children
+	for i, h := range hits {
+		if fields := parents[h.ParentID]; fields != nil {
+			hits[i].Link, _ = fields["link"].(string)
+			hits[i].Sources = vectorstorage.StringsOf(fields["sources"])
+			hits[i].Title, _ = fields["title"].(string)
+			hits[i].Text, _ = fields["text"].(string)
+		}
+	}
+	return hits, nil
+}
+
+// denseHits converts vector search hits
+func denseHits(hits []pinecone.Hit) []retrieval.Hit {
+	out := make([]retrieval.Hit, 0, len(hits))
+	for _, h := range hits {
+		hit := retrieval.Hit{
+			ID:    h.Id,
+			Score: float64(h.Score),
+		}
+		hit.ParentID, _ = h.Fields["parent"].(string)
+		hit.Link, _ = h.Fields["link"].(string)
+		hit.Sources = vectorstorage.StringsOf(h.Fields["sources"])
+		hit.Title, _ = h.Fields["title"].(string)
+		hit.Text, _ = h.Fields["text"].(string)
+		hit.Weight, _ = h.Fields["weight"].(float64)
+		hit.Meta = h.Fields
+		out = append(out, hit)
+	}
+	return out
+}
+
+// chunkHit converts a chunk and the metadata of its page for the keyword
+// index
+func chunkHit(v chunk.Chunk, meta map[string]any) retrieval.Hit {
+	return retrieval.Hit{
+		ID:       v.ID,
+		ParentID: v.ParentID,
+		Link:     v.Link,
+		Sources:  v.Sources,
+		Title:    v.Title,
+		Text:     v.Content,
+		Weight:   v.Weight,
+		Meta:     meta,
+	}
+}
+
+// parentNamespace holds the parent chunks of the children indexed in ns
+func parentNamespace(ns string) string {
+	return ns + "-parents"
+}
+
+// chunkRecord converts a chunk and the metadata of its page to the record
+// stored in the vector store
+func chunkRecord(v chunk.Chunk, meta map[string]any) *pinecone.IntegratedRecord {
+	record := pinecone.IntegratedRecord{
+		"id":      v.ID,
+		"text":    v.Content,
+		"link":    v.Link,
+		"sources": v.Sources,
+		"title":   v.Title,
+		"section": v.Section,
+		"ordinal": v.Ordinal,
+		"start":   v.StartByte,
+		"end":     v.EndByte,
+		"weight":  v.Weight,
+		"parent":  v.ParentID,
+	}
+	for k, m := range meta {
+		record[k] = m
+	}
+	return &record
+}
+
+// postParts chunks the posts of a thread separately, each led by a label
+// such as "Accepted answer by ann (12 votes)" and weighted by postWeight
+func postParts(posts []scrape.Post) []chunk.Part {
+	var parts []chunk.Part
+	for _, post := range posts {
+		// Extractors may not know the kind of a post; it then gets no label
+		var label string
+		if post.Kind != "" {
+			label = strings.ToUpper(string(post.Kind[:1])) + string(post.Kind[1:])
+			if post.Accepted {
+				label = "Accepted " + string(post.Kind)
+			}
+			if post.Author != "" {
+				label += " by " + post.Author
+			}
+			if post.Score != 0 {
+				label += fmt.Sprintf(" (%d votes)", post.Score)
+			}
+		}
+		content := post.Content
+		if label != "" {
+			content = label + "\n\n" + content
+		}
+		parts = append(parts, chunk.Part{
+			Label:   label,
+			Content: content,
+			Weight:  postWeight(post),
+		})
+	}
+	return parts
+}
+
+// postWeight favours accepted and upvoted answers and discounts comments
+// and downvoted posts
+func postWeight(post scrape.Post) float64 {
+	switch {
+	case post.Kind == scrape.PostComment:
+		return 0.6
+	case post.Score < 0:
+		return 0.7
+	}
+	w := 1 + min(math.Log10(1+float64(post.Score))*0.2, 0.4)
+	if post.Accepted {
+		w += 0.3
+	}
+	return w
+}
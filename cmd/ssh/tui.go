@@ -0,0 +1,459 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ary82/goseek/internal/answer"
+	"github.com/ary82/goseek/internal/conversation"
+	"github.com/ary82/goseek/internal/mode"
+	"github.com/ary82/goseek/internal/scrape"
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// TUI Model
+type model struct {
+	pipeline   *GoSeekPipeline
+	textarea   textarea.Model
+	viewport   viewport.Model
+	help       help.Model
+	spinner    spinner.Model
+	ready      bool
+	processing bool
+	// response   string
+	query     string
+	sessionID string
+	width     int
+	height    int
+	// user and locale are passed with every query
+	user   string
+	locale string
+	// mode is the answer mode of the next queries
+	mode string
+	// conv is the thread the queries are asked in
+	conv *conversation.Conversation
+}
+
+type processMsg struct {
+	answer *Answer
+	// standalone is the query searched for a follow-up
+	standalone string
+	err        error
+}
+
+// Key bindings
+type keyMap struct {
+	Submit key.Binding
+	Mode   key.Binding
+	New    key.Binding
+	Quit   key.Binding
+	Help   key.Binding
+}
+
+func (k keyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Submit, k.Mode, k.New, k.Help, k.Quit}
+}
+
+func (k keyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{k.Submit, k.Mode, k.New, k.Help},
+		{k.Quit},
+	}
+}
+
+var keys = keyMap{
+	Submit: key.NewBinding(
+		key.WithKeys("ctrl+s"),
+		key.WithHelp("ctrl+s", "submit query"),
+	),
+	Mode: key.NewBinding(
+		key.WithKeys("ctrl+o"),
+		key.WithHelp("ctrl+o", "next mode"),
+	),
+	New: key.NewBinding(
+		key.WithKeys("ctrl+n"),
+		key.WithHelp("ctrl+n", "new thread"),
+	),
+	Quit: key.NewBinding(
+		key.WithKeys("ctrl+c", "q"),
+		key.WithHelp("ctrl+c/q", "quit"),
+	),
+	// Help: key.NewBinding(
+	// key.WithKeys("?"),
+	// key.WithHelp("?", "toggle help"),
+	// ),
+}
+
+// Styles
+var (
+	titleStyle = lipgloss.NewStyle().
+			Bold(true).
+			Foreground(lipgloss.Color("#7D56F4")).
+			Padding(0, 1)
+
+	responseStyle = lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(lipgloss.Color("#874BFD")).
+			Padding(1, 2).
+			Margin(1, 0)
+
+	inputStyle = lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(lipgloss.Color("#04B575")).
+			Padding(0, 1)
+
+	processingStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#FFA500")).
+			Bold(true)
+
+	errorStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#FF6B6B")).
+			Bold(true)
+
+	failureStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("241"))
+
+	unsupportedStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("#FFA500")).
+				Underline(true)
+
+	citationNumberStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("#7D56F4")).
+				Bold(true)
+
+	citationURLStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("#04B575")).
+				Underline(true)
+)
+
+func initialModel(pipeline *GoSeekPipeline, sessionID string, user string, locale string) model {
+	ta := textarea.New()
+	ta.Placeholder = "Ask me anything..."
+	ta.Focus()
+	ta.Prompt = "┃ "
+	ta.CharLimit = 500
+	ta.SetWidth(80)
+	ta.SetHeight(3)
+	ta.FocusedStyle.CursorLine = lipgloss.NewStyle()
+	ta.ShowLineNumbers = false
+
+	vp := viewport.New(80, 20)
+	vp.SetContent(welcomeView())
+
+	sp := spinner.New()
+	sp.Spinner = spinner.Dot
+	sp.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("205"))
+
+	return model{
+		pipeline:  pipeline,
+		textarea:  ta,
+		viewport:  vp,
+		help:      help.New(),
+		spinner:   sp,
+		sessionID: sessionID,
+		user:      user,
+		locale:    locale,
+		mode:      mode.Default,
+		conv:      pipeline.NewConversation(sessionID),
+		ready:     true,
+	}
+}
+
+func (m model) Init() tea.Cmd {
+	return tea.Batch(textarea.Blink, m.spinner.Tick)
+}
+
+func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var (
+		tiCmd tea.Cmd
+		vpCmd tea.Cmd
+		spCmd tea.Cmd
+	)
+
+	m.textarea, tiCmd = m.textarea.Update(msg)
+	m.viewport, vpCmd = m.viewport.Update(msg)
+	m.spinner, spCmd = m.spinner.Update(msg)
+
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+
+		headerHeight := lipgloss.Height(m.headerView())
+		footerHeight := lipgloss.Height(m.footerView())
+		verticalMarginHeight := headerHeight + footerHeight
+
+		if !m.ready {
+			m.viewport = viewport.New(msg.Width, msg.Height-verticalMarginHeight)
+			m.viewport.YPosition = headerHeight
+			m.ready = true
+		} else {
+			m.viewport.Width = msg.Width
+			m.viewport.Height = msg.Height - verticalMarginHeight
+		}
+
+		m.textarea.SetWidth(msg.Width - 4)
+
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, keys.Quit):
+			return m, tea.Quit
+		case key.Matches(msg, keys.Mode):
+			m.mode = nextMode(m.mode)
+			return m, nil
+		case key.Matches(msg, keys.New):
+			if m.processing {
+				return m, nil
+			}
+			m.conv = m.pipeline.NewConversation(m.sessionID)
+			m.viewport.SetContent(welcomeView())
+			return m, nil
+		case key.Matches(msg, keys.Submit):
+			if m.processing {
+				return m, nil
+			}
+			query := strings.TrimSpace(m.textarea.Value())
+			// "/report" switches the mode, and "/report query" also asks
+			if name, rest, ok := modeCommand(query); ok {
+				if _, err := mode.Parse(name); err != nil {
+					m.viewport.SetContent(errorStyle.Render("Error: "+err.Error()) + "\n\n" + modesView())
+					return m, nil
+				}
+				m.mode = name
+				m.textarea.Reset()
+				query = rest
+			}
+			if query == "" {
+				return m, nil
+			}
+			m.query = query
+			m.processing = true
+			m.textarea.Reset()
+			return m, tea.Batch(
+				m.processQuery(query),
+				m.spinner.Tick,
+			)
+		}
+
+	case processMsg:
+		m.processing = false
+		if msg.err != nil {
+			content := errorStyle.Render("Error: "+msg.err.Error()) + "\n\n" + m.viewport.View()
+			m.viewport.SetContent(content)
+		} else {
+			flagged := msg.answer.Flagged(func(s string) string {
+				return unsupportedStyle.Render(s + " ⚠")
+			})
+			style := responseStyle.Width(m.viewport.Width - 4)
+			if msg.answer.Format == mode.FormatTable {
+				// Wrapping would break the table's rows
+				style = responseStyle
+			}
+			styledResponse := style.Render(flagged)
+			query := m.query
+			if msg.standalone != "" && msg.standalone != m.query {
+				query += failureStyle.Render(" (searched: " + msg.standalone + ")")
+			}
+			content := fmt.Sprintf("🔍 Query: %s\n\n%s\n%s%s%s\n%s",
+				query,
+				styledResponse,
+				groundingView(msg.answer.Answer),
+				citationsView(msg.answer.Citations),
+				failuresView(msg.answer.Failed),
+				threadView(m.conv.Turns()),
+			)
+			m.viewport.SetContent(content)
+			m.viewport.GotoTop()
+		}
+	}
+
+	return m, tea.Batch(tiCmd, vpCmd, spCmd)
+}
+
+func (m model) processQuery(query string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 180*time.Second)
+		defer cancel()
+
+		answer, standalone, err := m.pipeline.Ask(ctx, m.conv, query, QueryOptions{
+			User:   m.user,
+			Mode:   m.mode,
+			Locale: m.locale,
+		})
+		return processMsg{answer: answer, standalone: standalone, err: err}
+	}
+}
+
+func welcomeView() string {
+	return "Welcome to SSH GoSeek! 🔍\n\nType your question and press Ctrl+S to search. " +
+		"Follow-up questions build on the thread; Ctrl+N starts a new one.\n\n" + modesView()
+}
+
+// threadView lists the earlier questions of the thread, newest first,
+// under the latest answer
+func threadView(turns []conversation.Turn) string {
+	if len(turns) < 2 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("Earlier in this thread:\n")
+	for i := len(turns) - 2; i >= 0; i-- {
+		t := turns[i]
+		fmt.Fprintf(&b, "  › %s\n", t.Query)
+		summary, _, _ := strings.Cut(strings.TrimSpace(t.Answer), "\n")
+		if len(summary) > 120 {
+			summary = strings.ToValidUTF8(summary[:120], "") + "…"
+		}
+		fmt.Fprintf(&b, "    %s\n", summary)
+	}
+	return failureStyle.Render(b.String())
+}
+
+// modeCommand splits input such as "/report query" into a mode name and
+// the query, or returns the input as the query
+func modeCommand(input string) (string, string, bool) {
+	if !strings.HasPrefix(input, "/") {
+		return "", input, false
+	}
+	name, rest, _ := strings.Cut(input[1:], " ")
+	if name == "" || strings.Contains(name, "/") {
+		// A path such as /etc/hosts is part of the query
+		return "", input, false
+	}
+	return strings.ToLower(name), strings.TrimSpace(rest), true
+}
+
+func nextMode(current string) string {
+	names := mode.Names()
+	for i, name := range names {
+		if name == current {
+			return names[(i+1)%len(names)]
+		}
+	}
+	return mode.Default
+}
+
+// modesView lists the answer modes and how to pick one
+func modesView() string {
+	var b strings.Builder
+	b.WriteString("Answer modes (Ctrl+O, or start the query with /<mode>):\n")
+	for _, m := range mode.Modes {
+		fmt.Fprintf(&b, "  /%-8s %s\n", m.Name, m.Description)
+	}
+	return failureStyle.Render(b.String())
+}
+
+// groundingView summarizes how much of the answer its sources support
+func groundingView(ans answer.Answer) string {
+	if ans.Claims == nil {
+		return ""
+	}
+
+	line := fmt.Sprintf("Confidence: %.0f%%", ans.Confidence*100)
+	if n := len(ans.Unsupported()); n > 0 {
+		line += fmt.Sprintf(" · %d of %d claim(s) not found in the sources, marked ⚠", n, len(ans.Claims))
+		return unsupportedStyle.Render(line) + "\n\n"
+	}
+	return failureStyle.Render(line) + "\n\n"
+}
+
+// citationsView is the legend of the sources cited in the answer
+func citationsView(citations []answer.Citation) string {
+	if len(citations) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("Sources:\n")
+	for _, c := range citations {
+		title := c.Title
+		if title == "" {
+			title = c.URL
+		}
+		fmt.Fprintf(&b, "  %s %s\n", citationNumberStyle.Render(fmt.Sprintf("[%d]", c.N)), title)
+		fmt.Fprintf(&b, "      %s\n", citationURLStyle.Render(c.URL))
+		for _, also := range c.Also {
+			fmt.Fprintf(&b, "      also at %s\n", citationURLStyle.Render(also))
+		}
+		if c.Snippet != "" {
+			fmt.Fprintf(&b, "      %s\n", failureStyle.Render(c.Snippet))
+		}
+	}
+	return b.String() + "\n"
+}
+
+// failuresView lists the sources that could not be scraped and why
+func failuresView(failed []scrape.ScrapedContent) string {
+	if len(failed) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Skipped %d source(s):\n", len(failed))
+	for _, f := range failed {
+		reason := string(scrape.KindOf(f.Error))
+		var se *scrape.ScrapeError
+		if errors.As(f.Error, &se) && se.StatusCode != 0 {
+			reason = fmt.Sprintf("HTTP %d", se.StatusCode)
+		}
+		if reason == "" {
+			reason = f.Error.Error()
+		}
+		fmt.Fprintf(&b, "  • %s (%s)\n", f.URL, reason)
+	}
+	return failureStyle.Render(b.String())
+}
+
+func (m model) View() string {
+	if !m.ready {
+		return "\n  Initializing..."
+	}
+
+	return fmt.Sprintf("%s\n%s\n%s",
+		m.headerView(),
+		m.viewport.View(),
+		m.footerView(),
+	)
+}
+
+func (m model) headerView() string {
+	title := titleStyle.Render("SSH GoSeek")
+	status := ""
+	if m.processing {
+		status = processingStyle.Render(fmt.Sprintf(" %s Processing...", m.spinner.View()))
+	}
+	line := strings.Repeat("─", max(0, m.width-lipgloss.Width(title+status)))
+	return lipgloss.JoinHorizontal(lipgloss.Center, title, line, status)
+}
+
+func (m model) footerView() string {
+	info := lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Render(
+		fmt.Sprintf("Session: %s · Mode: %s", m.sessionID, m.mode),
+	)
+
+	inputArea := inputStyle.Render(m.textarea.View())
+
+	help := m.help.ShortHelpView(keys.ShortHelp())
+
+	gap := strings.Repeat(" ", max(0, m.width-lipgloss.Width(info)-lipgloss.Width(help)))
+	topLine := lipgloss.JoinHorizontal(lipgloss.Center, info, gap, help)
+
+	return lipgloss.JoinVertical(lipgloss.Left, topLine, inputArea)
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
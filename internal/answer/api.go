@@ -0,0 +1,49 @@
+package answer
+
+import (
+	"context"
+
+	"github.com/ary82/goseek/internal/retrieval"
+)
+
+// Citation is a source the answer refers to as [N]
+type Citation struct {
+	N       int
+	URL     string
+	Title   string
+	Snippet string
+	// Also lists the other links the cited text was found on
+	Also []string
+}
+
+// Answer is the text generated for a query and the sources it cites, in
+// order of N. Every [n] marker in Text has a Citation.
+type Answer struct {
+	Text      string
+	Citations []Citation
+	// Claims are the checked sentences of Text, set by a Verifier
+	Claims []Claim
+	// Confidence is the share of the answer supported by its sources, from
+	// 0 to 1, set by a Verifier
+	Confidence float64
+}
+
+// Claim is a sentence of an answer and whether the sources it cites
+// support it. A sentence without citations is checked against every source.
+type Claim struct {
+	Text string
+	// Start and End are the byte offsets of the claim in Answer.Text
+	Start     int
+	End       int
+	Citations []int
+	Supported bool
+	// Score is the support found for the claim, from 0 to 1
+	Score float64
+}
+
+// Verifier checks the claims of an answer against the sources of its
+// context and returns the answer with Claims and Confidence set. An error
+// may come with claims checked by a fallback, which are still usable.
+type Verifier interface {
+	Verify(ctx context.Context, ans Answer, sources []retrieval.Source) (Answer, error)
+}
@@ -0,0 +1,89 @@
+package answer
+
+import (
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ary82/goseek/internal/retrieval"
+)
+
+// snippetLen bounds the length of a citation snippet in bytes
+const snippetLen = 160
+
+// citationMarker matches code or a subscript written straight after an
+// identifier, such as arr[0], which are left alone, or a citation marker
+// such as [2] or [1, 3]
+var citationMarker = regexp.MustCompile("(?s)```.*?```|`[^`\n]*`|\\w\\[\\d+(?:\\s*,\\s*\\d+)*\\]|\\s?\\[(\\d+(?:\\s*,\\s*\\d+)*)\\]")
+
+// FromResponse builds an Answer from the LLM response to a context of
+// numbered sources. Markers citing numbers that are not among sources are
+// dropped, and only cited sources become Citations.
+func FromResponse(response string, sources []retrieval.Source) Answer {
+	byN := make(map[int]retrieval.Source, len(sources))
+	for _, s := range sources {
+		byN[s.N] = s
+	}
+
+	cited := make(map[int]bool)
+	text := citationMarker.ReplaceAllStringFunc(response, func(m string) string {
+		sub := citationMarker.FindStringSubmatch(m)
+		if sub[1] == "" {
+			return m
+		}
+
+		var valid []string
+		for _, field := range strings.Split(sub[1], ",") {
+			field = strings.TrimSpace(field)
+			n, err := strconv.Atoi(field)
+			if _, ok := byN[n]; err != nil || !ok || slices.Contains(valid, field) {
+				continue
+			}
+			cited[n] = true
+			valid = append(valid, field)
+		}
+		if len(valid) == 0 {
+			return ""
+		}
+		lead := m[:len(m)-len(strings.TrimLeft(m, " \t\n"))]
+		return lead + "[" + strings.Join(valid, ", ") + "]"
+	})
+
+	ans := Answer{Text: strings.TrimSpace(text)}
+	for n := range cited {
+		s := byN[n]
+		ans.Citations = append(ans.Citations, Citation{
+			N:       n,
+			URL:     s.Link,
+			Title:   s.Title,
+			Snippet: snippet(s.Passages),
+			Also:    s.Also,
+		})
+	}
+	sort.Slice(ans.Citations, func(i, j int) bool {
+		return ans.Citations[i].N < ans.Citations[j].N
+	})
+	return ans
+}
+
+// snippet is the start of the first passage, cut after a word
+func snippet(passages []string) string {
+	if len(passages) == 0 {
+		return ""
+	}
+	text := strings.Join(strings.Fields(passages[0]), " ")
+	if len(text) <= snippetLen {
+		return text
+	}
+	cut := snippetLen
+	for cut > 0 && !utf8.RuneStart(text[cut]) {
+		cut--
+	}
+	if space := strings.LastIndexByte(text[:cut], ' '); space > snippetLen/2 {
+		cut = space
+	}
+	return text[:cut] + "…"
+}
@@ -0,0 +1,72 @@
+package answer_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ary82/goseek/internal/answer"
+	"github.com/ary82/goseek/internal/retrieval"
+)
+
+func TestFromResponse(t *testing.T) {
+	sources := []retrieval.Source{
+		{N: 1, Link: "https://a.example.com", Title: "A", Passages: []string{"Alpha   text."}, Also: []string{"https://mirror.example.com"}},
+		{N: 2, Link: "https://b.example.com", Title: "B", Passages: []string{"Beta text."}},
+		{N: 3, Link: "https://c.example.com", Passages: []string{"Gamma text."}},
+	}
+	citation := func(n int) answer.Citation {
+		s := sources[n-1]
+		return answer.Citation{N: n, URL: s.Link, Title: s.Title, Snippet: map[int]string{1: "Alpha text.", 2: "Beta text.", 3: "Gamma text."}[n], Also: s.Also}
+	}
+
+	tests := []struct {
+		name     string
+		response string
+		want     answer.Answer
+	}{
+		{
+			name:     "test valid citations",
+			response: "Go is fast [2]. It compiles quickly [1, 2].",
+			want: answer.Answer{
+				Text:      "Go is fast [2]. It compiles quickly [1, 2].",
+				Citations: []answer.Citation{citation(1), citation(2)},
+			},
+		},
+		{
+			name:     "test hallucinated citations",
+			response: "Go is fast [7]. It has generics [3, 9, 3].",
+			want: answer.Answer{
+				Text:      "Go is fast. It has generics [3].",
+				Citations: []answer.Citation{citation(3)},
+			},
+		},
+		{
+			name:     "test code is kept",
+			response: "Index with `xs[5]`:\n```go\nys[9] = 1\n```\nas shown [1].",
+			want: answer.Answer{
+				Text:      "Index with `xs[5]`:\n```go\nys[9] = 1\n```\nas shown [1].",
+				Citations: []answer.Citation{citation(1)},
+			},
+		},
+		{
+			name:     "test subscripts are kept",
+			response: "Set arr[0] to 1 [2]. Read x[1, 2] next [1].",
+			want: answer.Answer{
+				Text:      "Set arr[0] to 1 [2]. Read x[1, 2] next [1].",
+				Citations: []answer.Citation{citation(1), citation(2)},
+			},
+		},
+		{
+			name:     "test no citations",
+			response: "No related information found in the context.",
+			want:     answer.Answer{Text: "No related information found in the context."},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := answer.FromResponse(tt.response, sources); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FromResponse() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
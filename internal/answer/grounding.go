@@ -0,0 +1,307 @@
+package answer
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/ary82/goseek/internal/constants"
+	"github.com/ary82/goseek/internal/llm"
+	"github.com/ary82/goseek/internal/retrieval"
+)
+
+const (
+	// minClaimWords is the number of content words a sentence needs to be
+	// checked; shorter ones such as "Here is how:" assert nothing
+	minClaimWords = 3
+	// DefaultSupportThreshold is the share of a claim's content words that
+	// its sources must contain for the lexical check to support it
+	DefaultSupportThreshold = 0.6
+)
+
+// sentenceEnd matches the end of a sentence, including a citation marker
+// written after its full stop
+var sentenceEnd = regexp.MustCompile(`[.!?]+(?:\s*\[\d+(?:\s*,\s*\d+)*\])?(?:\s+|$)`)
+
+// markerNumbers matches the citation markers of a claim, and subscripts
+// such as arr[0], whose leading identifier character is captured
+var markerNumbers = regexp.MustCompile(`(\w?)\[(\d+(?:\s*,\s*\d+)*)\]`)
+
+// listMarker matches the heading, list and quote markers leading a line
+var listMarker = regexp.MustCompile(`^\s*(?:#{1,6}\s+|[-*+]\s+|\d+[.)]\s+|>\s*)*`)
+
+// SplitClaims splits text into its sentences that assert something, with
+// the sources each one cites. Code blocks, headings without a full stop and
+// sentences with fewer than minClaimWords content words are left out.
+func SplitClaims(text string) []Claim {
+	var (
+		claims []Claim
+		inCode bool
+		offset int
+	)
+	for _, line := range strings.SplitAfter(text, "\n") {
+		start := offset
+		offset += len(line)
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inCode = !inCode
+			continue
+		}
+		if inCode || strings.HasPrefix(strings.TrimSpace(line), "|") {
+			// Code and table rows are not sentences
+			continue
+		}
+
+		lead := len(listMarker.FindString(line))
+		body := line[lead:]
+		for len(strings.TrimSpace(body)) > 0 {
+			end := len(body)
+			if loc := sentenceEnd.FindStringIndex(body); loc != nil {
+				end = loc[1]
+			}
+			sentence := strings.TrimSpace(body[:end])
+			at := start + lead + strings.Index(body, sentence)
+			if len(contentWords(sentence)) >= minClaimWords {
+				claims = append(claims, Claim{
+					Text:      sentence,
+					Start:     at,
+					End:       at + len(sentence),
+					Citations: citedNumbers(sentence),
+				})
+			}
+			lead += end
+			body = body[end:]
+		}
+	}
+	return claims
+}
+
+func citedNumbers(sentence string) []int {
+	var cited []int
+	for _, m := range markerNumbers.FindAllStringSubmatch(sentence, -1) {
+		if m[1] != "" {
+			continue
+		}
+		for _, field := range strings.Split(m[2], ",") {
+			if n, err := strconv.Atoi(strings.TrimSpace(field)); err == nil {
+				cited = append(cited, n)
+			}
+		}
+	}
+	return cited
+}
+
+// stopwords carry no claim of their own
+var stopwords = map[string]bool{
+	"a": true, "about": true, "after": true, "all": true, "also": true, "an": true, "and": true,
+	"any": true, "are": true, "as": true, "at": true, "be": true, "because": true, "been": true,
+	"before": true, "being": true, "both": true, "but": true, "by": true, "can": true, "could": true,
+	"do": true, "does": true, "each": true, "for": true, "from": true, "has": true, "have": true,
+	"here": true, "how": true, "if": true, "in": true, "into": true, "is": true, "it": true,
+	"its": true, "may": true, "more": true, "most": true, "must": true, "not": true, "of": true,
+	"on": true, "or": true, "other": true, "should": true, "so": true, "some": true, "such": true,
+	"than": true, "that": true, "the": true, "their": true, "them": true, "then": true,
+	"there": true, "these": true, "they": true, "this": true, "those": true, "to": true,
+	"use": true, "used": true, "using": true, "was": true, "we": true, "were": true, "what": true,
+	"when": true, "which": true, "while": true, "will": true, "with": true, "would": true,
+	"you": true, "your": true,
+}
+
+// contentWords returns the lowercased words of text that are not
+// stopwords or citation markers, without a plural s
+func contentWords(text string) []string {
+	text = markerNumbers.ReplaceAllString(text, "${1} ")
+	tokens := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r) && !strings.ContainsRune("._-", r)
+	})
+
+	var words []string
+	for _, tok := range tokens {
+		tok = strings.Trim(tok, "._-")
+		if tok == "" || stopwords[tok] {
+			continue
+		}
+		words = append(words, stem(tok))
+	}
+	return words
+}
+
+func stem(word string) string {
+	if len(word) > 3 && strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss") {
+		return word[:len(word)-1]
+	}
+	return word
+}
+
+func isNumber(word string) bool {
+	return strings.IndexFunc(word, unicode.IsNumber) != -1
+}
+
+type lexicalVerifier struct {
+	threshold float64
+}
+
+// NewLexicalVerifier supports a claim when its cited sources contain at
+// least threshold of its content words. Every number or version the sources
+// do not contain halves the score, as they are what is most often made up.
+func NewLexicalVerifier(threshold float64) Verifier {
+	if threshold <= 0 {
+		threshold = DefaultSupportThreshold
+	}
+	return &lexicalVerifier{
+		threshold: threshold,
+	}
+}
+
+func (lv *lexicalVerifier) Verify(ctx context.Context, ans Answer, sources []retrieval.Source) (Answer, error) {
+	vocab := make(map[int]map[string]bool, len(sources))
+	for _, s := range sources {
+		words := make(map[string]bool)
+		for _, p := range s.Passages {
+			for _, w := range contentWords(p) {
+				words[w] = true
+			}
+		}
+		vocab[s.N] = words
+	}
+
+	claims := SplitClaims(ans.Text)
+	for i, c := range claims {
+		claims[i].Score = lexicalSupport(c, vocab)
+		claims[i].Supported = claims[i].Score >= lv.threshold
+	}
+	return withClaims(ans, claims), nil
+}
+
+// lexicalSupport is the share of the claim's content words found in the
+// sources it cites, or in any source when it cites none
+func lexicalSupport(c Claim, vocab map[int]map[string]bool) float64 {
+	cited := c.Citations
+	if len(cited) == 0 {
+		for n := range vocab {
+			cited = append(cited, n)
+		}
+	}
+
+	words := contentWords(c.Text)
+	if len(words) == 0 {
+		return 0
+	}
+	var found int
+	penalty := 1.0
+	for _, w := range words {
+		if slices.ContainsFunc(cited, func(n int) bool { return vocab[n][w] }) {
+			found++
+		} else if isNumber(w) {
+			penalty /= 2
+		}
+	}
+	return float64(found) / float64(len(words)) * penalty
+}
+
+type llmVerifier struct {
+	llm      llm.LLM
+	fallback Verifier
+}
+
+// NewLLMVerifier asks the LLM whether the cited sources entail each claim,
+// all claims in one request. Claims it gives no verdict for keep the
+// verdict of the lexical check, as do all claims when the LLM request fails.
+func NewLLMVerifier(l llm.LLM) Verifier {
+	return &llmVerifier{
+		llm:      l,
+		fallback: NewLexicalVerifier(DefaultSupportThreshold),
+	}
+}
+
+// verdictLine matches a verdict such as "3: yes" or "(3) unsupported"
+var verdictLine = regexp.MustCompile(`(?im)^\W*(\d+)\W+(yes|no|supported|unsupported)\b`)
+
+func (lv *llmVerifier) Verify(ctx context.Context, ans Answer, sources []retrieval.Source) (Answer, error) {
+	checked, err := lv.fallback.Verify(ctx, ans, sources)
+	if err != nil || len(checked.Claims) == 0 {
+		return checked, err
+	}
+	claims := checked.Claims
+
+	var list strings.Builder
+	for i, c := range claims {
+		fmt.Fprintf(&list, "(%d) %s\n", i+1, c.Text)
+	}
+
+	prompt := fmt.Sprintf(constants.GROUNDING_PROMPT, retrieval.FormatSources(sources), list.String())
+	response, err := lv.llm.GenerateContent(ctx, prompt)
+	if err != nil {
+		return checked, fmt.Errorf("error verifying answer with LLM: %w", err)
+	}
+
+	var judged int
+	for _, m := range verdictLine.FindAllStringSubmatch(*response, -1) {
+		i, err := strconv.Atoi(m[1])
+		if err != nil || i < 1 || i > len(claims) {
+			continue
+		}
+		verdict := strings.ToLower(m[2])
+		claims[i-1].Supported = verdict == "yes" || verdict == "supported"
+		claims[i-1].Score = 0
+		if claims[i-1].Supported {
+			claims[i-1].Score = 1
+		}
+		judged++
+	}
+	log.Printf("llm verification judged %v of %v claims", judged, len(claims))
+	return withClaims(ans, claims), nil
+}
+
+// withClaims sets the claims of ans and its confidence, the mean claim
+// score weighted by claim length. An answer without claims asserts nothing
+// and is fully confident.
+func withClaims(ans Answer, claims []Claim) Answer {
+	ans.Claims = claims
+	ans.Confidence = 1
+
+	var score, total float64
+	for _, c := range claims {
+		words := float64(len(contentWords(c.Text)))
+		score += c.Score * words
+		total += words
+	}
+	if total > 0 {
+		ans.Confidence = score / total
+	}
+	return ans
+}
+
+// Unsupported returns the claims the sources do not support
+func (a Answer) Unsupported() []Claim {
+	var out []Claim
+	for _, c := range a.Claims {
+		if !c.Supported {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// Flagged returns Text with every unsupported claim passed through flag,
+// e.g. to highlight it
+func (a Answer) Flagged(flag func(string) string) string {
+	var (
+		b    strings.Builder
+		last int
+	)
+	for _, c := range a.Claims {
+		if c.Supported || c.Start < last || c.End > len(a.Text) {
+			continue
+		}
+		b.WriteString(a.Text[last:c.Start])
+		b.WriteString(flag(a.Text[c.Start:c.End]))
+		last = c.End
+	}
+	b.WriteString(a.Text[last:])
+	return b.String()
+}
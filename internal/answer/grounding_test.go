@@ -0,0 +1,174 @@
+package answer_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/ary82/goseek/internal/answer"
+	"github.com/ary82/goseek/internal/retrieval"
+)
+
+type llmMock struct {
+	response string
+	err      error
+}
+
+func (m *llmMock) GenerateContent(ctx context.Context, prompt string) (*string, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &m.response, nil
+}
+
+var groundingSources = []retrieval.Source{
+	{N: 1, Link: "https://go.dev", Passages: []string{"Go 1.22 changed loop variables to be per-iteration."}},
+	{N: 2, Link: "https://example.com", Passages: []string{"The garbage collector is concurrent and has low pause times."}},
+}
+
+func TestSplitClaims(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{
+			name: "test sentences",
+			text: "Go 1.22 changed loop variables [1]. The garbage collector is concurrent. [2] Nice!",
+			want: []string{"Go 1.22 changed loop variables [1].", "The garbage collector is concurrent. [2]"},
+		},
+		{
+			name: "test markdown",
+			text: "## Loop variables\n\n- Each iteration gets a new variable [1].\n```go\nfor i := range xs { go f(i) }\n```\n| a | b |",
+			want: []string{"Each iteration gets a new variable [1]."},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, c := range answer.SplitClaims(tt.text) {
+				if tt.text[c.Start:c.End] != c.Text {
+					t.Errorf("SplitClaims() claim %q at [%d:%d] = %q", c.Text, c.Start, c.End, tt.text[c.Start:c.End])
+				}
+				got = append(got, c.Text)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SplitClaims() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitClaims_subscripts(t *testing.T) {
+	claims := answer.SplitClaims("Setting the slice element arr[1] to zero clears the first entry [2].")
+	if len(claims) != 1 || !reflect.DeepEqual(claims[0].Citations, []int{2}) {
+		t.Errorf("SplitClaims() = %+v, want one claim citing [2]", claims)
+	}
+}
+
+func TestLexicalVerifier_Verify(t *testing.T) {
+	tests := []struct {
+		name           string
+		text           string
+		wantSupported  []bool
+		wantConfidence float64
+	}{
+		{
+			name:           "test supported",
+			text:           "Go 1.22 changed loop variables [1]. The garbage collector has low pause times [2].",
+			wantSupported:  []bool{true, true},
+			wantConfidence: 1,
+		},
+		{
+			name:          "test wrong source",
+			text:          "Go 1.22 changed loop variables [2].",
+			wantSupported: []bool{false},
+		},
+		{
+			name:          "test made up version",
+			text:          "Go 1.21 changed loop variables [1].",
+			wantSupported: []bool{false},
+		},
+		{
+			name:           "test uncited is checked against every source",
+			text:           "The garbage collector is concurrent.",
+			wantSupported:  []bool{true},
+			wantConfidence: 1,
+		},
+		{
+			name:           "test no claims",
+			text:           "Done!",
+			wantConfidence: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := answer.NewLexicalVerifier(0).Verify(context.Background(), answer.Answer{Text: tt.text}, groundingSources)
+			if err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+			var supported []bool
+			for _, c := range got.Claims {
+				supported = append(supported, c.Supported)
+			}
+			if !reflect.DeepEqual(supported, tt.wantSupported) {
+				t.Errorf("Verify() supported = %v, want %v", supported, tt.wantSupported)
+			}
+			if tt.wantConfidence == 1 && got.Confidence != 1 {
+				t.Errorf("Verify() confidence = %v, want 1", got.Confidence)
+			}
+			if tt.wantConfidence == 0 && got.Confidence >= 1 {
+				t.Errorf("Verify() confidence = %v, want less than 1", got.Confidence)
+			}
+		})
+	}
+}
+
+func TestLLMVerifier_Verify(t *testing.T) {
+	text := "Go 1.22 changed loop variables [1]. The collector stops the world for seconds [2]."
+
+	tests := []struct {
+		name          string
+		response      string
+		err           error
+		wantSupported []bool
+		wantErr       bool
+	}{
+		{name: "test verdicts", response: "1: yes\n2: no", wantSupported: []bool{true, false}},
+		{name: "test missing verdict keeps lexical", response: "(2) unsupported", wantSupported: []bool{true, false}},
+		{name: "test overrides lexical", response: "1: no\n2: yes", wantSupported: []bool{false, true}},
+		{name: "test llm error keeps lexical", err: errors.New("quota exceeded"), wantSupported: []bool{true, false}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := answer.NewLLMVerifier(&llmMock{response: tt.response, err: tt.err})
+			got, err := v.Verify(context.Background(), answer.Answer{Text: text}, groundingSources)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Verify() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var supported []bool
+			for _, c := range got.Claims {
+				supported = append(supported, c.Supported)
+			}
+			if !reflect.DeepEqual(supported, tt.wantSupported) {
+				t.Errorf("Verify() supported = %v, want %v", supported, tt.wantSupported)
+			}
+		})
+	}
+}
+
+func TestAnswer_Flagged(t *testing.T) {
+	ans, _ := answer.NewLexicalVerifier(0).Verify(context.Background(), answer.Answer{
+		Text: "Go 1.22 changed loop variables [1]. Go was designed on Mars [1].",
+	}, groundingSources)
+
+	got := ans.Flagged(func(s string) string { return "<" + s + ">" })
+	want := "Go 1.22 changed loop variables [1]. <Go was designed on Mars [1].>"
+	if got != want {
+		t.Errorf("Flagged() = %q, want %q", got, want)
+	}
+	if n := len(ans.Unsupported()); n != 1 {
+		t.Errorf("Unsupported() = %d claims, want 1", n)
+	}
+}
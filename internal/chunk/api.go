@@ -8,8 +8,37 @@ type Chunker interface {
 	Chunk(ctx context.Context, link string, str string) ([]Chunk, error)
 }
 
+// Tokenizer counts tokens the way the embedding model and LLM see them
+type Tokenizer interface {
+	Count(text string) int
+}
+
 type Chunk struct {
+	// ID is stable for a given link and content, see ChunkID
+	ID         string
 	Link       string
 	Content    string
 	TokenCount int
+	// Ordinal is the position of the chunk within its document
+	Ordinal int
+	// StartByte/EndByte and StartRune/EndRune locate the chunk in the text
+	// given to the chunker (the visible text for HTML, or the Part it came
+	// from), or are -1 when the chunk can't be matched to a contiguous span
+	StartByte int
+	EndByte   int
+	StartRune int
+	EndRune   int
+	// Title is the title of the source page
+	Title string
+	// ParentID is the ID of the larger chunk this one was split from, see
+	// Children
+	ParentID string
+	// Section is the heading path of the chunk, e.g. "Install > Linux > Arch"
+	Section string
+	// Sources lists every link the chunk was found on when near-duplicates
+	// are collapsed by a Deduper
+	Sources []string
+	// Weight scales the relevance of the chunk in retrieval, e.g. to favour
+	// accepted answers over comments; 1 is neutral
+	Weight float64
 }
@@ -0,0 +1,151 @@
+package chunk
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"slices"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+const (
+	// shingleSize is the number of words per shingle
+	shingleSize = 3
+	// dedupBands and dedupRows split a MinHash signature for locality
+	// sensitive hashing; chunks sharing any band are compared
+	dedupBands = 32
+	dedupRows  = 4
+)
+
+// Deduper collapses near-duplicate chunks, e.g. syndicated articles and
+// mirrored docs, using MinHash over word shingles. It is safe for
+// concurrent use.
+type Deduper struct {
+	// Threshold is the estimated Jaccard similarity at or above which two
+	// chunks are duplicates
+	Threshold float64
+
+	mu      sync.Mutex
+	kept    []dedupEntry
+	buckets map[uint64][]int
+}
+
+type dedupEntry struct {
+	chunk     Chunk
+	signature []uint64
+}
+
+func NewDeduper(threshold float64) *Deduper {
+	return &Deduper{
+		Threshold: threshold,
+		buckets:   make(map[uint64][]int),
+	}
+}
+
+// Add records c and reports whether it is new. A near-duplicate of an
+// earlier chunk is dropped: its link is added to the Sources of the
+// surviving chunk, which is returned instead.
+func (d *Deduper) Add(c Chunk) (Chunk, bool) {
+	sig := minHash(shingles(c.Content))
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	seen := make(map[int]bool)
+	for band := range dedupBands {
+		for _, i := range d.buckets[bandKey(sig, band)] {
+			if seen[i] {
+				continue
+			}
+			seen[i] = true
+			if similarity(sig, d.kept[i].signature) < d.Threshold {
+				continue
+			}
+			survivor := &d.kept[i].chunk
+			if !slices.Contains(survivor.Sources, c.Link) {
+				survivor.Sources = append(slices.Clone(survivor.Sources), c.Link)
+			}
+			return *survivor, false
+		}
+	}
+
+	c.Sources = []string{c.Link}
+	d.kept = append(d.kept, dedupEntry{chunk: c, signature: sig})
+	for band := range dedupBands {
+		key := bandKey(sig, band)
+		d.buckets[key] = append(d.buckets[key], len(d.kept)-1)
+	}
+	return c, true
+}
+
+// shingles returns the hashes of the overlapping word n-grams of text,
+// ignoring case and punctuation
+func shingles(text string) []uint64 {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	if len(words) < shingleSize {
+		return []uint64{hashString(strings.Join(words, " "))}
+	}
+	hashes := make([]uint64, 0, len(words)-shingleSize+1)
+	for i := 0; i+shingleSize <= len(words); i++ {
+		hashes = append(hashes, hashString(strings.Join(words[i:i+shingleSize], " ")))
+	}
+	return hashes
+}
+
+func hashString(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	return h.Sum64()
+}
+
+// minHash computes a signature whose matching positions estimate the
+// Jaccard similarity of two shingle sets
+func minHash(hashes []uint64) []uint64 {
+	sig := make([]uint64, dedupBands*dedupRows)
+	for i := range sig {
+		sig[i] = ^uint64(0)
+	}
+	for _, h := range hashes {
+		for i := range sig {
+			if v := mix(h ^ uint64(i+1)*0x9e3779b97f4a7c15); v < sig[i] {
+				sig[i] = v
+			}
+		}
+	}
+	return sig
+}
+
+// mix is the splitmix64 finalizer
+func mix(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
+
+func bandKey(sig []uint64, band int) uint64 {
+	h := fnv.New64a()
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], uint64(band))
+	h.Write(buf[:])
+	for _, v := range sig[band*dedupRows : (band+1)*dedupRows] {
+		binary.LittleEndian.PutUint64(buf[:], v)
+		h.Write(buf[:])
+	}
+	return h.Sum64()
+}
+
+func similarity(a []uint64, b []uint64) float64 {
+	same := 0
+	for i := range a {
+		if a[i] == b[i] {
+			same++
+		}
+	}
+	return float64(same) / float64(len(a))
+}
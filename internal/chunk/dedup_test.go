@@ -0,0 +1,63 @@
+package chunk_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/ary82/goseek/internal/chunk"
+)
+
+func TestDeduper_Add(t *testing.T) {
+	article := "The city council approved the new transit plan on Monday, adding three bus lines and extending service hours on weekends for riders across the northern districts."
+
+	tests := []struct {
+		name        string
+		content     string
+		link        string
+		wantNew     bool
+		wantSources []string
+	}{
+		{
+			name:        "test first copy",
+			content:     article,
+			link:        "https://a.example.com/news",
+			wantNew:     true,
+			wantSources: []string{"https://a.example.com/news"},
+		},
+		{
+			name:        "test syndicated copy",
+			content:     "Reuters - " + article + " Read more.",
+			link:        "https://b.example.com/story",
+			wantNew:     false,
+			wantSources: []string{"https://a.example.com/news", "https://b.example.com/story"},
+		},
+		{
+			name:        "test exact mirror",
+			content:     article,
+			link:        "https://mirror.example.org/news",
+			wantNew:     false,
+			wantSources: []string{"https://a.example.com/news", "https://b.example.com/story", "https://mirror.example.org/news"},
+		},
+		{
+			name:        "test unrelated chunk",
+			content:     "Rust's borrow checker rejects programs with data races at compile time, so concurrent code that compiles is free of them by construction.",
+			link:        "https://b.example.com/story",
+			wantNew:     true,
+			wantSources: []string{"https://b.example.com/story"},
+		},
+	}
+
+	// Cases run in order against one deduper
+	d := chunk.NewDeduper(0.7)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, gotNew := d.Add(chunk.Chunk{Link: tt.link, Content: tt.content})
+			if gotNew != tt.wantNew {
+				t.Errorf("Add() new = %v, want %v", gotNew, tt.wantNew)
+			}
+			if !slices.Equal(got.Sources, tt.wantSources) {
+				t.Errorf("Add() sources = %q, want %q", got.Sources, tt.wantSources)
+			}
+		})
+	}
+}
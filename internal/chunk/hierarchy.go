@@ -0,0 +1,52 @@
+package chunk
+
+import (
+	"context"
+	"strings"
+)
+
+// Children splits every parent chunk into smaller chunks with c, for
+// indexing small chunks that match precisely while handing the LLM their
+// larger parent. Children carry their parent's ID, section, title, sources
+// and weight, are numbered across all parents, and their offsets locate them
+// within the parent's content, after its section prefix. A parent too short
+// to split becomes its own single child, so that it can still be found.
+func Children(ctx context.Context, c Chunker, parents []Chunk) ([]Chunk, error) {
+	var children []Chunk
+	for _, parent := range parents {
+		body := parent.Content
+		prefix := ""
+		if parent.Section != "" && strings.HasPrefix(body, parent.Section+"\n\n") {
+			prefix = parent.Section + "\n\n"
+			body = strings.TrimPrefix(body, prefix)
+		}
+
+		chunks, err := c.Chunk(ctx, parent.Link, body)
+		if err != nil {
+			return nil, err
+		}
+		if len(chunks) == 0 && strings.TrimSpace(body) != "" {
+			chunks = []Chunk{{
+				Link:       parent.Link,
+				Content:    body,
+				TokenCount: parent.TokenCount,
+			}}
+			annotate(chunks, body)
+		}
+		for _, child := range chunks {
+			// Keep the section prefix so children embed with their context
+			child.Content = prefix + child.Content
+			// Derived from the parent so a child that is its whole parent
+			// still gets its own ID
+			child.ID = ChunkID(parent.ID, child.Content)
+			child.ParentID = parent.ID
+			child.Ordinal = len(children)
+			child.Title = parent.Title
+			child.Section = parent.Section
+			child.Sources = parent.Sources
+			child.Weight = parent.Weight
+			children = append(children, child)
+		}
+	}
+	return children, nil
+}
@@ -0,0 +1,78 @@
+package chunk_test
+
+import (
+	"context"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/ary82/goseek/internal/chunk"
+)
+
+func TestChildren(t *testing.T) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	md := "# Install\n\n## Linux\n\n" + strings.Repeat("Run the installer with root privileges and follow the prompts. ", 12) +
+		"\n\n## Windows\n\nDouble-click the setup file."
+	parents, err := chunk.NewStructuredChunker(512, 0, nil).Chunk(context.Background(), "https://example.com", md)
+	if err != nil {
+		t.Fatalf("Chunk() failed: %v", err)
+	}
+
+	children, err := chunk.Children(context.Background(), chunk.NewTextChunker(40, 0, 0, nil), parents)
+	if err != nil {
+		t.Fatalf("Children() failed: %v", err)
+	}
+	if len(children) <= len(parents) {
+		t.Fatalf("Children() returned %v children for %v parents, want more", len(children), len(parents))
+	}
+
+	byID := make(map[string]chunk.Chunk)
+	for _, p := range parents {
+		byID[p.ID] = p
+	}
+	for i, c := range children {
+		parent, ok := byID[c.ParentID]
+		if !ok {
+			t.Fatalf("child %d has unknown parent %q", i, c.ParentID)
+		}
+		if c.Ordinal != i || c.Section != parent.Section || c.ID == "" || c.ID == parent.ID {
+			t.Errorf("child %d = %+v, want ordinal %v, section %q and its own ID", i, c, i, parent.Section)
+		}
+		if !strings.HasPrefix(c.Content, parent.Section+"\n\n") {
+			t.Errorf("child %d content %q lacks section prefix %q", i, c.Content, parent.Section)
+		}
+		body := strings.TrimPrefix(c.Content, parent.Section+"\n\n")
+		if !strings.Contains(parent.Content, body) {
+			t.Errorf("child %d content %q not found in parent", i, body)
+		}
+	}
+}
+
+func TestChildren_shortParent(t *testing.T) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	parent := chunk.Chunk{
+		ID:         "p1",
+		Link:       "https://forum.example.com/t/1",
+		Content:    "Answer\n\nSame here.",
+		Section:    "Answer",
+		TokenCount: 5,
+		Weight:     1.5,
+	}
+	children, err := chunk.Children(context.Background(), chunk.NewTextChunker(128, 16, 0.1, nil), []chunk.Chunk{parent})
+	if err != nil {
+		t.Fatalf("Children() failed: %v", err)
+	}
+	if len(children) != 1 {
+		t.Fatalf("Children() returned %v children, want the parent as its only child", len(children))
+	}
+	c := children[0]
+	if c.Content != parent.Content || c.ParentID != parent.ID || c.Weight != parent.Weight || c.ID == parent.ID {
+		t.Errorf("child = %+v, want the content of parent %+v under its own ID", c, parent)
+	}
+}
@@ -0,0 +1,188 @@
+package chunk
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Document is a source text to be chunked. A document with Parts, such as
+// the posts of a forum thread, is chunked part by part instead of as a whole.
+type Document struct {
+	Link    string
+	Title   string
+	Content string
+	Parts   []Part
+	// Meta is not used for chunking and is passed through to the Result
+	Meta map[string]any
+}
+
+// Part is a separately chunked piece of a document
+type Part struct {
+	// Label becomes the Section of the part's chunks, e.g. "Accepted answer"
+	Label   string
+	Content string
+	// Weight is copied to the part's chunks unless zero
+	Weight float64
+}
+
+// Result holds the chunks of the Index-th document received by ChunkStream
+type Result struct {
+	Index  int
+	Doc    Document
+	Chunks []Chunk
+	Err    error
+}
+
+// ChunkStream chunks documents from docs with up to workers goroutines,
+// emitting one Result per document as soon as it is done. The returned
+// channel is closed once docs is closed and drained. After ctx is cancelled
+// the remaining documents are drained with ctx.Err() as their error.
+func ChunkStream(ctx context.Context, c Chunker, docs <-chan Document, workers int) <-chan Result {
+	out := make(chan Result)
+
+	type job struct {
+		index int
+		doc   Document
+	}
+	jobs := make(chan job)
+	wg := sync.WaitGroup{}
+
+	for range max(workers, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				res := Result{Index: j.index, Doc: j.doc}
+				if err := ctx.Err(); err != nil {
+					res.Err = err
+				} else {
+					res.Chunks, res.Err = chunkDocument(ctx, c, j.doc)
+				}
+				out <- res
+			}
+		}()
+	}
+
+	// Number documents in arrival order
+	go func() {
+		defer func() {
+			close(jobs)
+			wg.Wait()
+			close(out)
+		}()
+		i := 0
+		for doc := range docs {
+			jobs <- job{index: i, doc: doc}
+			i++
+		}
+	}()
+
+	return out
+}
+
+// InOrder re-emits the results of ChunkStream by Index, holding back those
+// that finish before an earlier document, so consumers see the documents in
+// the order they were sent whatever the worker scheduling
+func InOrder(results <-chan Result) <-chan Result {
+	out := make(chan Result)
+	go func() {
+		defer close(out)
+		held := make(map[int]Result)
+		next := 0
+		for res := range results {
+			held[res.Index] = res
+			for {
+				r, ok := held[next]
+				if !ok {
+					break
+				}
+				delete(held, next)
+				out <- r
+				next++
+			}
+		}
+	}()
+	return out
+}
+
+// chunkDocument chunks doc, or each of its parts in order
+func chunkDocument(ctx context.Context, c Chunker, doc Document) ([]Chunk, error) {
+	parts := doc.Parts
+	if len(parts) == 0 {
+		parts = []Part{{Content: doc.Content}}
+	}
+
+	var chunks []Chunk
+	for i, part := range parts {
+		partChunks, err := c.Chunk(ctx, doc.Link, part.Content)
+		if err != nil {
+			return nil, err
+		}
+		for _, pc := range partChunks {
+			if pc.Section == "" {
+				pc.Section = part.Label
+			}
+			if pc.Title == "" {
+				pc.Title = doc.Title
+			}
+			if part.Weight != 0 {
+				pc.Weight = part.Weight
+			}
+			if len(doc.Parts) > 0 {
+				// Identical parts, such as repeated "+1" replies, must not
+				// share an ID and overwrite each other
+				pc.ID = ChunkID(fmt.Sprintf("%s#%d", doc.Link, i), pc.Content)
+			}
+			// Ordinals run across the parts; offsets stay within the part
+			pc.Ordinal = len(chunks)
+			chunks = append(chunks, pc)
+		}
+	}
+	return chunks, nil
+}
+
+// ChunkAll chunks docs concurrently with up to workers goroutines. Chunks
+// are returned in document order, so the output matches chunking the
+// documents one by one. It stops at the first error.
+func ChunkAll(ctx context.Context, c Chunker, docs []Document, workers int) ([]Chunk, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	in := make(chan Document)
+	go func() {
+		defer close(in)
+		for _, d := range docs {
+			select {
+			case in <- d:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	perDoc := make([][]Chunk, len(docs))
+	var firstErr error
+	for res := range ChunkStream(ctx, c, in, workers) {
+		if res.Err != nil {
+			if firstErr == nil {
+				firstErr = res.Err
+			}
+			cancel()
+			continue
+		}
+		perDoc[res.Index] = res.Chunks
+	}
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var all []Chunk
+	for _, chunks := range perDoc {
+		all = append(all, chunks...)
+	}
+	return all, nil
+}
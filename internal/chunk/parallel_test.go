@@ -0,0 +1,141 @@
+package chunk_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"reflect"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ary82/goseek/internal/chunk"
+)
+
+// largePages builds n distinct pages of roughly size bytes each
+func largePages(n int, size int) []chunk.Document {
+	docs := make([]chunk.Document, n)
+	for i := range docs {
+		var b strings.Builder
+		for j := 0; b.Len() < size; j++ {
+			fmt.Fprintf(&b, "Page %d paragraph %d talks about nanomaterials and their uses. ", i, j)
+			if j%8 == 7 {
+				b.WriteString("\n")
+			}
+		}
+		docs[i] = chunk.Document{
+			Link:    fmt.Sprintf("https://example.com/%d", i),
+			Content: b.String(),
+		}
+	}
+	return docs
+}
+
+func TestChunkAll(t *testing.T) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	tc := chunk.NewTextChunker(128, 16, 0.1, nil)
+	docs := largePages(12, 8*1024)
+
+	var want []chunk.Chunk
+	for _, d := range docs {
+		c, err := tc.Chunk(context.Background(), d.Link, d.Content)
+		if err != nil {
+			t.Fatalf("Chunk() failed: %v", err)
+		}
+		want = append(want, c...)
+	}
+
+	got, err := chunk.ChunkAll(context.Background(), tc, docs, 4)
+	if err != nil {
+		t.Fatalf("ChunkAll() failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ChunkAll() returned %v chunks out of order, want %v in document order", len(got), len(want))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := chunk.ChunkAll(ctx, tc, docs, 4); err == nil {
+		t.Error("ChunkAll() with cancelled context succeeded unexpectedly")
+	}
+}
+
+func BenchmarkChunkAll(b *testing.B) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	tc := chunk.NewTextChunker(512, 64, 0.1, nil)
+	for _, pages := range []int{10, 50} {
+		docs := largePages(pages, 64*1024)
+		for _, workers := range slices.Compact([]int{1, runtime.GOMAXPROCS(0)}) {
+			b.Run(fmt.Sprintf("pages=%d/workers=%d", pages, workers), func(b *testing.B) {
+				for b.Loop() {
+					if _, err := chunk.ChunkAll(context.Background(), tc, docs, workers); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestChunkAll_parts(t *testing.T) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	doc := chunk.Document{
+		Link:  "https://forum.example.com/t/1",
+		Title: "Upgrade fails",
+		Parts: []chunk.Part{
+			{Label: "Question", Content: "The upgrade fails with a lock error."},
+			{Label: "Accepted answer", Content: "Remove the stale lock file first.", Weight: 1.5},
+		},
+	}
+	got, err := chunk.ChunkAll(context.Background(), chunk.NewTextChunker(128, 0, 0, nil), []chunk.Document{doc}, 2)
+	if err != nil {
+		t.Fatalf("ChunkAll() failed: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("ChunkAll() returned %v chunks, want 2", len(got))
+	}
+	for i, want := range []struct {
+		section string
+		weight  float64
+	}{{"Question", 1}, {"Accepted answer", 1.5}} {
+		c := got[i]
+		if c.Section != want.section || c.Weight != want.weight || c.Ordinal != i || c.Title != doc.Title {
+			t.Errorf("chunk %d = %+v, want section %q, weight %v, ordinal %v", i, c, want.section, want.weight, i)
+		}
+	}
+
+	// Identical replies keep their own chunks
+	doc.Parts = []chunk.Part{{Content: "Same here."}, {Content: "Same here."}}
+	got, err = chunk.ChunkAll(context.Background(), chunk.NewTextChunker(128, 0, 0, nil), []chunk.Document{doc}, 2)
+	if err != nil {
+		t.Fatalf("ChunkAll() failed: %v", err)
+	}
+	if len(got) != 2 || got[0].ID == got[1].ID {
+		t.Errorf("ChunkAll() of identical parts = %+v, want 2 chunks with distinct IDs", got)
+	}
+}
+
+func TestInOrder(t *testing.T) {
+	results := make(chan chunk.Result, 5)
+	for _, i := range []int{2, 0, 4, 1, 3} {
+		results <- chunk.Result{Index: i}
+	}
+	close(results)
+
+	var got []int
+	for res := range chunk.InOrder(results) {
+		got = append(got, res.Index)
+	}
+	if want := []int{0, 1, 2, 3, 4}; !reflect.DeepEqual(got, want) {
+		t.Errorf("InOrder() = %v, want %v", got, want)
+	}
+}
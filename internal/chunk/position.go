@@ -0,0 +1,141 @@
+package chunk
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ChunkID derives a stable ID from the source URL and the chunk content, so
+// re-chunking the same page yields the same IDs and upserts are idempotent
+func ChunkID(link string, content string) string {
+	sum := sha256.Sum256([]byte(link + "\x00" + content))
+	return hex.EncodeToString(sum[:16])
+}
+
+// annotate sets the ID, ordinal, neutral weight and source offsets of chunks
+// cut from source
+func annotate(chunks []Chunk, source string) {
+	idx := newTextIndex(source)
+	cursor := 0
+	for i := range chunks {
+		c := &chunks[i]
+		c.ID = ChunkID(c.Link, c.Content)
+		c.Ordinal = i
+		c.Weight = 1
+
+		body := c.Content
+		if c.Section != "" {
+			body = strings.TrimPrefix(body, c.Section+"\n\n")
+		}
+		start, end, ok := idx.find(body, cursor)
+		if !ok {
+			c.StartByte, c.EndByte, c.StartRune, c.EndRune = -1, -1, -1, -1
+			continue
+		}
+		// Overlapping chunks may start before the previous one ends
+		cursor = idx.normPos(start) + 1
+
+		c.StartByte, c.EndByte = start, end
+		c.StartRune = utf8.RuneCountInString(source[:start])
+		c.EndRune = c.StartRune + utf8.RuneCountInString(source[start:end])
+	}
+}
+
+// textIndex matches chunk text against a source regardless of how
+// whitespace was changed by chunking
+type textIndex struct {
+	norm string
+	// orig[i] is the source byte offset of norm byte i
+	orig []int
+}
+
+func newTextIndex(source string) *textIndex {
+	var b strings.Builder
+	orig := make([]int, 0, len(source)+1)
+	space := false
+	for i, r := range source {
+		if unicode.IsSpace(r) {
+			space = b.Len() > 0
+			continue
+		}
+		if space {
+			b.WriteByte(' ')
+			orig = append(orig, i)
+			space = false
+		}
+		b.WriteRune(r)
+		for k := range utf8.RuneLen(r) {
+			orig = append(orig, i+k)
+		}
+	}
+	return &textIndex{
+		norm: b.String(),
+		orig: orig,
+	}
+}
+
+// normPos maps a source offset back to the normalized text
+func (ti *textIndex) normPos(src int) int {
+	lo, hi := 0, len(ti.orig)
+	for lo < hi {
+		mid := (lo + hi) / 2
+		if ti.orig[mid] < src {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+	return lo
+}
+
+// anchorLen is how much of a chunk's head and tail is matched when the
+// chunk is not a contiguous span of the source
+const anchorLen = 64
+
+// find returns the source span of text, searching from normalized offset from
+func (ti *textIndex) find(text string, from int) (int, int, bool) {
+	needle := strings.Join(strings.Fields(text), " ")
+	if needle == "" || from > len(ti.norm) {
+		return 0, 0, false
+	}
+
+	if pos := strings.Index(ti.norm[from:], needle); pos != -1 {
+		start := from + pos
+		return ti.orig[start], ti.end(start + len(needle)), true
+	}
+
+	// Fall back to locating the head and the tail separately
+	head := headBytes(needle, anchorLen)
+	tail := tailRunes(needle, anchorLen)
+	pos := strings.Index(ti.norm[from:], head)
+	if pos == -1 {
+		return 0, 0, false
+	}
+	start := from + pos
+	endPos := strings.Index(ti.norm[start:], tail)
+	if endPos == -1 {
+		return 0, 0, false
+	}
+	end := start + endPos + len(tail)
+	return ti.orig[start], ti.end(end), true
+}
+
+// end maps the normalized end offset of a match to the source; the last
+// matched byte is never whitespace, so it maps exactly
+func (ti *textIndex) end(normEnd int) int {
+	return ti.orig[normEnd-1] + 1
+}
+
+// headBytes returns roughly the first n bytes of text, ending on a rune boundary
+func headBytes(text string, n int) string {
+	if n >= len(text) {
+		return text
+	}
+	for n > 0 && !utf8.RuneStart(text[n]) {
+		n--
+	}
+	return text[:n]
+}
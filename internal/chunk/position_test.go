@@ -0,0 +1,72 @@
+package chunk
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func Test_annotate(t *testing.T) {
+	tests := []struct {
+		name    string
+		chunker Chunker
+		content string
+	}{
+		{
+			name:    "test text chunker",
+			chunker: &TextChunker{Maxsize: 40, Minsize: 1, ChunkOverlap: 0.2},
+			content: numbered("Première phrase %d avec des accents.\n  Zweiter Satz über Größe.  ", 12),
+		},
+		{
+			name:    "test structured chunker",
+			chunker: &StructuredChunker{Maxsize: 40, Minsize: 1},
+			content: "# Intro\n\n" + numbered("日本語の文%dです。 ", 30) + "\n\n## Next\n\nShort   spaced\ttext here.",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.chunker.Chunk(context.Background(), "https://example.com", tt.content)
+			if err != nil {
+				t.Fatalf("Chunk() failed: %v", err)
+			}
+			if len(got) < 2 {
+				t.Fatalf("Chunk() returned %v chunks, want several", len(got))
+			}
+
+			ids := make(map[string]bool)
+			for i, c := range got {
+				if c.Ordinal != i {
+					t.Errorf("chunk %v Ordinal = %v", i, c.Ordinal)
+				}
+				if c.ID != ChunkID(c.Link, c.Content) || ids[c.ID] {
+					t.Errorf("chunk %v ID = %v, not stable or not unique", i, c.ID)
+				}
+				ids[c.ID] = true
+
+				if c.StartByte < 0 {
+					t.Errorf("chunk %v not located: %q", i, c.Content)
+					continue
+				}
+				span := tt.content[c.StartByte:c.EndByte]
+				body := strings.TrimPrefix(c.Content, c.Section+"\n\n")
+				if strings.Join(strings.Fields(span), " ") != strings.Join(strings.Fields(body), " ") {
+					t.Errorf("chunk %v span = %q, want %q", i, span, body)
+				}
+				if c.StartRune != utf8.RuneCountInString(tt.content[:c.StartByte]) ||
+					c.EndRune-c.StartRune != utf8.RuneCountInString(span) {
+					t.Errorf("chunk %v rune offsets = %v-%v", i, c.StartRune, c.EndRune)
+				}
+			}
+		})
+	}
+}
+
+func numbered(format string, n int) string {
+	var b strings.Builder
+	for i := range n {
+		fmt.Fprintf(&b, format, i)
+	}
+	return b.String()
+}
@@ -0,0 +1,248 @@
+package chunk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ary82/goseek/internal/embed"
+)
+
+// Content types a profile can be selected by, see ContentTypeOf
+const (
+	ContentProse = "prose"
+	ContentCode  = "code"
+	ContentForum = "forum"
+)
+
+// Chunking strategies a profile can use
+const (
+	StrategyText       = "text"
+	StrategyStructured = "structured"
+	StrategySemantic   = "semantic"
+)
+
+// Profile is a named chunking strategy and its sizes, used for the pages
+// matching its Domains or ContentTypes
+type Profile struct {
+	Name      string  `json:"name"`
+	Strategy  string  `json:"strategy"`
+	Maxsize   int     `json:"maxsize"`
+	Minsize   int     `json:"minsize"`
+	Overlap   float64 `json:"overlap,omitempty"`
+	Threshold float64 `json:"threshold,omitempty"`
+	// Domains match a host and its subdomains, e.g. "python.org"
+	Domains      []string `json:"domains,omitempty"`
+	ContentTypes []string `json:"content_types,omitempty"`
+}
+
+// DefaultProfiles are used when no profile file is configured. The
+// "default" profile applies to pages no other profile matches.
+var DefaultProfiles = []Profile{
+	{Name: "default", Strategy: StrategyStructured, Maxsize: 512, Minsize: 64},
+	{Name: "code", Strategy: StrategyStructured, Maxsize: 768, Minsize: 64, ContentTypes: []string{ContentCode}},
+	{Name: "forum", Strategy: StrategyText, Maxsize: 256, Minsize: 32, Overlap: 0.1, ContentTypes: []string{ContentForum}},
+}
+
+// profileConfig is the layout of a profile file
+type profileConfig struct {
+	Profiles []Profile `json:"profiles"`
+}
+
+// ProfileRegistry is a Chunker that picks a profile for every page by domain
+// or content type. Profiles are read from a JSON file, which is reloaded when
+// it changes, so sizes can be tuned without a restart.
+type ProfileRegistry struct {
+	path      string
+	tokenizer Tokenizer
+	embedder  embed.Embedder
+
+	mu       sync.RWMutex
+	modTime  time.Time
+	profiles []Profile
+	chunkers map[string]Chunker
+}
+
+// NewProfileRegistry loads profiles from the JSON file at path, or uses
+// DefaultProfiles when path is empty. The embedder is only needed by
+// semantic profiles.
+func NewProfileRegistry(path string, tok Tokenizer, e embed.Embedder) (*ProfileRegistry, error) {
+	r := &ProfileRegistry{
+		path:      path,
+		tokenizer: tok,
+		embedder:  e,
+	}
+	if path == "" {
+		if err := r.set(slices.Clone(DefaultProfiles)); err != nil {
+			return nil, err
+		}
+		return r, nil
+	}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *ProfileRegistry) Chunk(ctx context.Context, link string, content string) ([]Chunk, error) {
+	p, c := r.Select(link, content)
+	log.Printf("chunk profile %q (%s, %d-%d tokens) for %s", p.Name, p.Strategy, p.Minsize, p.Maxsize, link)
+	// Only the structured chunker reads markup; the others would index tags
+	if p.Strategy != StrategyStructured && looksLikeHTML(content) {
+		content = htmlText(content)
+	}
+	return c.Chunk(ctx, link, content)
+}
+
+// Select returns the profile for a page and its chunker. A domain match wins
+// over a content type match, which wins over the "default" profile.
+func (r *ProfileRegistry) Select(link string, content string) (Profile, Chunker) {
+	if r.path != "" {
+		if err := r.reload(); err != nil {
+			log.Printf("keeping previous chunk profiles: %v", err)
+		}
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	host := ""
+	if u, err := url.Parse(link); err == nil {
+		host = strings.ToLower(u.Hostname())
+	}
+	for _, p := range r.profiles {
+		for _, d := range p.Domains {
+			if host == d || strings.HasSuffix(host, "."+d) {
+				return p, r.chunkers[p.Name]
+			}
+		}
+	}
+
+	ct := ContentTypeOf(link, content)
+	for _, p := range r.profiles {
+		if slices.Contains(p.ContentTypes, ct) {
+			return p, r.chunkers[p.Name]
+		}
+	}
+
+	for _, p := range r.profiles {
+		if p.Name == "default" {
+			return p, r.chunkers[p.Name]
+		}
+	}
+	return r.profiles[0], r.chunkers[r.profiles[0].Name]
+}
+
+// reload reads the profile file again if it changed since the last load
+func (r *ProfileRegistry) reload() error {
+	info, err := os.Stat(r.path)
+	if err != nil {
+		return fmt.Errorf("error reading chunk profiles: %w", err)
+	}
+	r.mu.RLock()
+	unchanged := info.ModTime().Equal(r.modTime)
+	r.mu.RUnlock()
+	if unchanged {
+		return nil
+	}
+
+	data, err := os.ReadFile(r.path)
+	if err != nil {
+		return fmt.Errorf("error reading chunk profiles: %w", err)
+	}
+	var cfg profileConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return fmt.Errorf("error parsing chunk profiles %s: %w", r.path, err)
+	}
+	if err := r.set(cfg.Profiles); err != nil {
+		return fmt.Errorf("error in chunk profiles %s: %w", r.path, err)
+	}
+
+	r.mu.Lock()
+	r.modTime = info.ModTime()
+	r.mu.Unlock()
+	log.Printf("loaded %v chunk profiles from %s", len(cfg.Profiles), r.path)
+	return nil
+}
+
+// set validates profiles and builds their chunkers
+func (r *ProfileRegistry) set(profiles []Profile) error {
+	if len(profiles) == 0 {
+		return fmt.Errorf("no profiles")
+	}
+	chunkers := make(map[string]Chunker, len(profiles))
+	for i := range profiles {
+		p := &profiles[i]
+		if p.Name == "" {
+			return fmt.Errorf("profile %d has no name", i)
+		}
+		if _, ok := chunkers[p.Name]; ok {
+			return fmt.Errorf("duplicate profile %q", p.Name)
+		}
+		if p.Maxsize <= 0 || p.Minsize < 0 || p.Minsize > p.Maxsize {
+			return fmt.Errorf("profile %q: invalid sizes %d-%d", p.Name, p.Minsize, p.Maxsize)
+		}
+		for j, d := range p.Domains {
+			p.Domains[j] = strings.ToLower(strings.TrimPrefix(d, "www."))
+		}
+
+		switch p.Strategy {
+		case StrategyText:
+			chunkers[p.Name] = NewTextChunker(p.Maxsize, p.Minsize, p.Overlap, r.tokenizer)
+		case StrategyStructured:
+			chunkers[p.Name] = NewStructuredChunker(p.Maxsize, p.Minsize, r.tokenizer)
+		case StrategySemantic:
+			if p.Threshold <= 0 || p.Threshold > 1 {
+				return fmt.Errorf("profile %q: threshold %v is not in (0, 1]", p.Name, p.Threshold)
+			}
+			if r.embedder == nil {
+				return fmt.Errorf("profile %q: semantic chunking needs an embedder", p.Name)
+			}
+			chunkers[p.Name] = NewSemanticChunker(r.embedder, p.Threshold, p.Minsize, p.Maxsize, r.tokenizer)
+		default:
+			return fmt.Errorf("profile %q: unknown strategy %q", p.Name, p.Strategy)
+		}
+	}
+
+	r.mu.Lock()
+	r.profiles = profiles
+	r.chunkers = chunkers
+	r.mu.Unlock()
+	return nil
+}
+
+var (
+	forumHosts = []string{"stackoverflow.com", "stackexchange.com", "superuser.com", "serverfault.com", "askubuntu.com", "reddit.com", "news.ycombinator.com", "discourse.org"}
+	forumPath  = regexp.MustCompile(`/(questions|t|r/[^/]+/comments|issues|discussions|threads?|topic)/`)
+	codeBlock  = regexp.MustCompile("(?m)^\\s*(```|~~~)|<pre[\\s>]")
+)
+
+// ContentTypeOf guesses the content type of a page from its link and content
+func ContentTypeOf(link string, content string) string {
+	u, err := url.Parse(link)
+	if err != nil {
+		return ContentProse
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, h := range forumHosts {
+		if host == h || strings.HasSuffix(host, "."+h) {
+			return ContentForum
+		}
+	}
+	if forumPath.MatchString(u.Path) {
+		return ContentForum
+	}
+	// Pages with several code blocks are documentation for code
+	if len(codeBlock.FindAllStringIndex(content, 3)) >= 3 {
+		return ContentCode
+	}
+	return ContentProse
+}
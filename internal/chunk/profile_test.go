@@ -0,0 +1,177 @@
+package chunk_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ary82/goseek/internal/chunk"
+	"github.com/ary82/goseek/internal/embed"
+)
+
+func TestContentTypeOf(t *testing.T) {
+	code := strings.Repeat("Example:\n\n```go\nfmt.Println()\n```\n\n", 3)
+
+	tests := []struct {
+		name    string
+		link    string
+		content string
+		want    string
+	}{
+		{name: "test prose", link: "https://example.com/blog/post", content: "Some text.", want: chunk.ContentProse},
+		{name: "test forum host", link: "https://unix.stackexchange.com/a/1", content: "Some text.", want: chunk.ContentForum},
+		{name: "test forum path", link: "https://forum.example.com/t/topic-name/42", content: "Some text.", want: chunk.ContentForum},
+		{name: "test code docs", link: "https://example.com/docs", content: code, want: chunk.ContentCode},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := chunk.ContentTypeOf(tt.link, tt.content); got != tt.want {
+				t.Errorf("ContentTypeOf() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProfileRegistry_Select(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "profiles.json")
+	write := func(content string, mod time.Time) {
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, mod, mod); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(`{"profiles": [
+		{"name": "default", "strategy": "structured", "maxsize": 512, "minsize": 64},
+		{"name": "forum", "strategy": "text", "maxsize": 256, "minsize": 32, "overlap": 0.1, "content_types": ["forum"]},
+		{"name": "python", "strategy": "semantic", "maxsize": 384, "minsize": 32, "threshold": 0.3, "domains": ["python.org"]}
+	]}`, time.Now().Add(-time.Hour))
+
+	e, err := embed.NewHashEmbedder(64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := chunk.NewProfileRegistry(path, nil, e)
+	if err != nil {
+		t.Fatalf("NewProfileRegistry() failed: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		link string
+		want string
+	}{
+		{name: "test domain", link: "https://docs.python.org/3/", want: "python"},
+		{name: "test content type", link: "https://stackoverflow.com/questions/1", want: "forum"},
+		{name: "test default", link: "https://example.com/", want: "default"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, _ := r.Select(tt.link, "Some text."); got.Name != tt.want {
+				t.Errorf("Select() = %v, want %v", got.Name, tt.want)
+			}
+		})
+	}
+
+	// A changed file is picked up, and an invalid one is ignored
+	write(`{"profiles": [{"name": "default", "strategy": "text", "maxsize": 128, "minsize": 16}]}`, time.Now())
+	if got, _ := r.Select("https://docs.python.org/3/", ""); got.Name != "default" || got.Maxsize != 128 {
+		t.Errorf("Select() after reload = %+v, want the reloaded default profile", got)
+	}
+	write(`{"profiles": [{"name": "default", "strategy": "fancy", "maxsize": 128}]}`, time.Now().Add(time.Hour))
+	if got, _ := r.Select("https://example.com/", ""); got.Strategy != chunk.StrategyText {
+		t.Errorf("Select() after invalid reload = %+v, want the previous profile", got)
+	}
+}
+
+func TestNewProfileRegistry(t *testing.T) {
+	tests := []struct {
+		name       string
+		config     string
+		noEmbedder bool
+		wantErr    bool
+	}{
+		{name: "test valid", config: `{"profiles": [{"name": "default", "strategy": "text", "maxsize": 128}]}`},
+		{name: "test valid semantic", config: `{"profiles": [{"name": "a", "strategy": "semantic", "maxsize": 64, "threshold": 0.3}]}`},
+		{name: "test empty", config: `{"profiles": []}`, wantErr: true},
+		{name: "test unknown strategy", config: `{"profiles": [{"name": "a", "strategy": "fancy", "maxsize": 128}]}`, wantErr: true},
+		{name: "test bad sizes", config: `{"profiles": [{"name": "a", "strategy": "text", "maxsize": 64, "minsize": 128}]}`, wantErr: true},
+		{name: "test semantic without embedder", config: `{"profiles": [{"name": "a", "strategy": "semantic", "maxsize": 64, "threshold": 0.3}]}`, noEmbedder: true, wantErr: true},
+		{name: "test semantic without threshold", config: `{"profiles": [{"name": "a", "strategy": "semantic", "maxsize": 64}]}`, wantErr: true},
+		{name: "test semantic threshold above 1", config: `{"profiles": [{"name": "a", "strategy": "semantic", "maxsize": 64, "threshold": 1.5}]}`, wantErr: true},
+		{name: "test malformed", config: `{"profiles": [`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "profiles.json")
+			if err := os.WriteFile(path, []byte(tt.config), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			e, err := embed.NewHashEmbedder(64)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.noEmbedder {
+				e = nil
+			}
+			_, gotErr := chunk.NewProfileRegistry(path, nil, e)
+			if (gotErr != nil) != tt.wantErr {
+				t.Errorf("NewProfileRegistry() error = %v, wantErr %v", gotErr, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestProfileRegistry_Chunk(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "profiles.json")
+	config := `{"profiles": [
+		{"name": "default", "strategy": "structured", "maxsize": 64, "minsize": 8},
+		{"name": "forum", "strategy": "text", "maxsize": 32, "minsize": 8, "overlap": 0.1, "domains": ["forum.example.com"]},
+		{"name": "news", "strategy": "semantic", "maxsize": 32, "minsize": 8, "threshold": 0.5, "domains": ["news.example.com"]}
+	]}`
+	if err := os.WriteFile(path, []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	e, err := embed.NewHashEmbedder(64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := chunk.NewProfileRegistry(path, nil, e)
+	if err != nil {
+		t.Fatalf("NewProfileRegistry() failed: %v", err)
+	}
+
+	page := `<html><body><nav><a href="/">Home</a></nav><article>
+<h1>Goroutines</h1>
+<p>A goroutine is a lightweight thread managed by the Go runtime. Starting one costs a few kilobytes of stack.</p>
+<p>Channels let goroutines <em>communicate</em> and synchronize without explicit locks or condition variables.</p>
+<table><tr><th>Op</th><th>Blocks</th></tr><tr><td>send</td><td>yes</td></tr></table>
+</article><script>var x = "<b>";</script></body></html>`
+
+	tests := []struct {
+		name string
+		link string
+	}{
+		{name: "test text", link: "https://forum.example.com/t/1"},
+		{name: "test semantic", link: "https://news.example.com/story"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chunks, err := r.Chunk(t.Context(), tt.link, page)
+			if err != nil {
+				t.Fatalf("Chunk() error = %v", err)
+			}
+			if len(chunks) == 0 {
+				t.Fatalf("Chunk() returned no chunks")
+			}
+			for _, c := range chunks {
+				if strings.Contains(c.Content, "<") {
+					t.Errorf("Chunk() chunk contains markup: %q", c.Content)
+				}
+			}
+		})
+	}
+}
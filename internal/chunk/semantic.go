@@ -0,0 +1,102 @@
+package chunk
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/ary82/goseek/internal/embed"
+)
+
+// SemanticChunker groups consecutive sentences and starts a new chunk where
+// the similarity between neighbouring sentences drops below Threshold. Chunks
+// grow to at least Minsize tokens before a topic shift may end them, and are
+// always ended before exceeding Maxsize.
+type SemanticChunker struct {
+	Embedder  embed.Embedder
+	Threshold float64
+	Minsize   int
+	Maxsize   int
+	// Tokenizer measures sizes in tokens; nil estimates 4 bytes per token
+	Tokenizer Tokenizer
+}
+
+func NewSemanticChunker(e embed.Embedder, threshold float64, minsize int, maxsize int, tok Tokenizer) Chunker {
+	return &SemanticChunker{
+		Embedder:  e,
+		Threshold: threshold,
+		Minsize:   minsize,
+		Maxsize:   maxsize,
+		Tokenizer: tok,
+	}
+}
+
+func (sc *SemanticChunker) Chunk(ctx context.Context, link string, content string) ([]Chunk, error) {
+	var sentences []string
+	for _, line := range strings.Split(content, "\n") {
+		sentences = append(sentences, splitSentences(line)...)
+	}
+	if len(sentences) == 0 {
+		return []Chunk{}, nil
+	}
+
+	vectors, err := sc.Embedder.Embed(ctx, sentences)
+	if err != nil {
+		return nil, fmt.Errorf("error embedding sentences: %w", err)
+	}
+	if len(vectors) != len(sentences) {
+		return nil, fmt.Errorf("embedder returned %d vectors for %d sentences", len(vectors), len(sentences))
+	}
+
+	var chunks []Chunk
+	emit := func(text string, size int) {
+		chunks = append(chunks, Chunk{
+			Link:       link,
+			Content:    text,
+			TokenCount: size,
+		})
+	}
+
+	// The size of the growing chunk is carried over, so every iteration
+	// counts the tokens of one candidate text
+	text, size := sentences[0], sc.tokens(sentences[0])
+	for i := 1; i < len(sentences); i++ {
+		joined := text + " " + sentences[i]
+		joinedSize := sc.tokens(joined)
+		overflow := joinedSize > sc.Maxsize
+		shift := size >= sc.Minsize && embed.Cosine(vectors[i-1], vectors[i]) < sc.Threshold
+
+		if overflow || shift {
+			emit(text, size)
+			text, size = sentences[i], sc.tokens(sentences[i])
+			continue
+		}
+		text, size = joined, joinedSize
+	}
+
+	// A short tail joins the previous chunk when it fits
+	if n := len(chunks); n > 0 && size < sc.Minsize {
+		merged := chunks[n-1].Content + " " + text
+		if mergedSize := sc.tokens(merged); mergedSize <= sc.Maxsize {
+			chunks[n-1].Content = merged
+			chunks[n-1].TokenCount = mergedSize
+			text = ""
+		}
+	}
+	if text != "" {
+		emit(text, size)
+	}
+
+	annotate(chunks, content)
+
+	log.Printf("semantic chunk succeeded with %v results", len(chunks))
+	return chunks, nil
+}
+
+func (sc *SemanticChunker) tokens(content string) int {
+	if sc.Tokenizer == nil {
+		return approxTokenizer{}.Count(content)
+	}
+	return sc.Tokenizer.Count(content)
+}
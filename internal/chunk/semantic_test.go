@@ -0,0 +1,63 @@
+package chunk_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ary82/goseek/internal/chunk"
+	"github.com/ary82/goseek/internal/embed"
+)
+
+func TestSemanticChunker_Chunk(t *testing.T) {
+	cats := "Cats are small domestic cats. Domestic cats sleep most of the day. Cats groom their fur daily."
+	rust := "The Rust compiler checks borrows. The Rust borrow checker rejects data races. Rust compiler errors explain borrows."
+
+	tests := []struct {
+		name    string
+		content string
+		maxsize int
+		want    []string
+	}{
+		{
+			name:    "test topic shift",
+			content: cats + " " + rust,
+			maxsize: 512,
+			want:    []string{cats, rust},
+		},
+		{
+			name:    "test maxsize",
+			content: cats,
+			maxsize: 16,
+			want: []string{
+				"Cats are small domestic cats. Domestic cats sleep most of the day.",
+				"Cats groom their fur daily.",
+			},
+		},
+	}
+	e, err := embed.NewHashEmbedder(256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sc := &chunk.SemanticChunker{
+				Embedder:  e,
+				Threshold: 0.1,
+				Minsize:   4,
+				Maxsize:   tt.maxsize,
+			}
+			got, gotErr := sc.Chunk(context.Background(), "https://example.com", tt.content)
+			if gotErr != nil {
+				t.Fatalf("Chunk() failed: %v", gotErr)
+			}
+			var contents []string
+			for _, c := range got {
+				contents = append(contents, c.Content)
+			}
+			if strings.Join(contents, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("Chunk() = %q, want %q", contents, tt.want)
+			}
+		})
+	}
+}
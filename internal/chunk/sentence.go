@@ -0,0 +1,154 @@
+package chunk
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// abbreviations never end a sentence when followed by a period
+var abbreviations = map[string]bool{
+	"mr": true, "mrs": true, "ms": true, "dr": true, "prof": true, "sr": true,
+	"jr": true, "st": true, "vs": true, "etc": true, "e.g": true, "i.e": true,
+	"cf": true, "al": true, "fig": true, "vol": true, "approx": true,
+	"inc": true, "ltd": true, "corp": true, "dept": true,
+	"u.s": true, "u.k": true, "a.m": true, "p.m": true, "ph.d": true,
+	"jan": true, "feb": true, "mar": true, "apr": true, "jun": true, "jul": true,
+	"aug": true, "sep": true, "sept": true, "oct": true, "nov": true, "dec": true,
+}
+
+// numberAbbreviations are also ordinary words, so they are only taken as
+// abbreviations before a number, as in "No. 5"
+var numberAbbreviations = map[string]bool{
+	"no": true,
+}
+
+// splitSentences segments text into trimmed sentences. It splits on . ! ?
+// followed by whitespace and on the CJK terminators 。！？ and leaves
+// abbreviations, initials, decimals, version numbers and URLs intact.
+func splitSentences(text string) []string {
+	var sentences []string
+	add := func(s string) {
+		if s = strings.TrimSpace(s); s != "" {
+			sentences = append(sentences, s)
+		}
+	}
+
+	start := 0
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		end := i + size
+
+		switch {
+		case isCJKTerminator(r):
+			// CJK sentences end without a following space
+			end = skipTerminators(text, end)
+			end = skipClosers(text, end)
+			add(text[start:end])
+			start = end
+		case r == '.' || r == '!' || r == '?' || r == '…':
+			end = skipTerminators(text, end)
+			end = skipClosers(text, end)
+			if !boundaryAfter(text, end) {
+				break
+			}
+			if r == '.' && !sentenceEndingPeriod(text[start:i], text[end:]) {
+				break
+			}
+			add(text[start:end])
+			start = end
+		}
+		i = end
+	}
+	add(text[start:])
+
+	return sentences
+}
+
+func isCJKTerminator(r rune) bool {
+	return r == '。' || r == '！' || r == '？' || r == '｡'
+}
+
+// skipTerminators consumes runs like "?!" or "..."
+func skipTerminators(text string, i int) int {
+	for i < len(text) {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		if r != '.' && r != '!' && r != '?' && r != '…' && !isCJKTerminator(r) {
+			break
+		}
+		i += size
+	}
+	return i
+}
+
+// skipClosers keeps closing quotes and brackets with the sentence they end
+func skipClosers(text string, i int) int {
+	for i < len(text) {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		if !strings.ContainsRune(`"')]}’”»」』）`, r) {
+			break
+		}
+		i += size
+	}
+	return i
+}
+
+// boundaryAfter requires whitespace or the end of text after a terminator,
+// which rules out "v1.2", "3.14" and "example.com/a.b"
+func boundaryAfter(text string, i int) bool {
+	if i >= len(text) {
+		return true
+	}
+	r, _ := utf8.DecodeRuneInString(text[i:])
+	return unicode.IsSpace(r)
+}
+
+// sentenceEndingPeriod decides whether a period followed by whitespace ends
+// the sentence, given the text before the period and after the whitespace
+func sentenceEndingPeriod(before string, after string) bool {
+	word := lastWord(before)
+	next := strings.TrimLeftFunc(after, unicode.IsSpace)
+	if abbreviations[strings.ToLower(word)] {
+		return false
+	}
+	if r, _ := utf8.DecodeRuneInString(next); numberAbbreviations[strings.ToLower(word)] && unicode.IsDigit(r) {
+		return false
+	}
+	// Initials such as "J. R. R. Tolkien"
+	if utf8.RuneCountInString(word) == 1 {
+		if r, _ := utf8.DecodeRuneInString(word); unicode.IsUpper(r) {
+			return false
+		}
+	}
+	// A lowercase continuation means the period was not a full stop
+	if r, _ := utf8.DecodeRuneInString(next); unicode.IsLower(r) {
+		return false
+	}
+	return true
+}
+
+// lastWord returns the trailing run of letters, digits and inner periods
+func lastWord(s string) string {
+	i := len(s)
+	for i > 0 {
+		r, size := utf8.DecodeLastRuneInString(s[:i])
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '.' {
+			break
+		}
+		i -= size
+	}
+	return strings.Trim(s[i:], ".")
+}
+
+// tailRunes returns roughly the last n bytes of text, starting on a rune
+// boundary
+func tailRunes(text string, n int) string {
+	if n >= len(text) {
+		return text
+	}
+	i := len(text) - n
+	for i < len(text) && !utf8.RuneStart(text[i]) {
+		i++
+	}
+	return text[i:]
+}
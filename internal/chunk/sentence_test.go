@@ -0,0 +1,133 @@
+package chunk
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode"
+	"unicode/utf8"
+)
+
+func Test_splitSentences(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{
+			name: "test simple",
+			text: "First one. Second one! Third one?",
+			want: []string{"First one.", "Second one!", "Third one?"},
+		},
+		{
+			name: "test abbreviations",
+			text: "Use a tool, e.g. grep or awk. Dr. Smith agrees.",
+			want: []string{"Use a tool, e.g. grep or awk.", "Dr. Smith agrees."},
+		},
+		{
+			name: "test words that look like abbreviations",
+			text: "The answer is no. Next, ask again. It is run by a co. The rest is est. Done. See No. 5 below.",
+			want: []string{"The answer is no.", "Next, ask again.", "It is run by a co.", "The rest is est.", "Done.", "See No. 5 below."},
+		},
+		{
+			name: "test numbers and versions",
+			text: "Upgrade to v1.2.3 today. Pi is 3.14 roughly.",
+			want: []string{"Upgrade to v1.2.3 today.", "Pi is 3.14 roughly."},
+		},
+		{
+			name: "test urls",
+			text: "See https://example.com/docs/index.html for more. Then continue.",
+			want: []string{"See https://example.com/docs/index.html for more.", "Then continue."},
+		},
+		{
+			name: "test initials",
+			text: "Written by J. R. R. Tolkien. It is long.",
+			want: []string{"Written by J. R. R. Tolkien.", "It is long."},
+		},
+		{
+			name: "test quotes and ellipsis",
+			text: `He said "stop." Then... Nothing?! Fine.`,
+			want: []string{`He said "stop."`, "Then...", "Nothing?!", "Fine."},
+		},
+		{
+			name: "test cjk",
+			text: "今日は晴れです。明日は雨ですか？「はい！」そうです",
+			want: []string{"今日は晴れです。", "明日は雨ですか？", "「はい！」", "そうです"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitSentences(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitSentences() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func FuzzSplitSentences(f *testing.F) {
+	for _, seed := range []string{
+		"Hello world. How are you?",
+		"e.g. v1.2 at https://x.io/a.b. OK!",
+		"日本語の文。次の文！",
+		"……。」",
+		"a.b.c. d",
+	} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, text string) {
+		if !utf8.ValidString(text) {
+			t.Skip()
+		}
+		sentences := splitSentences(text)
+		for _, s := range sentences {
+			if !utf8.ValidString(s) {
+				t.Fatalf("splitSentences(%q) produced invalid UTF-8 %q", text, s)
+			}
+		}
+		// Nothing but whitespace may be lost
+		if got, want := stripSpace(strings.Join(sentences, "")), stripSpace(text); got != want {
+			t.Fatalf("splitSentences(%q) lost content: %q", text, got)
+		}
+	})
+}
+
+func FuzzTextChunker_Chunk(f *testing.F) {
+	for _, seed := range []string{
+		strings.Repeat("Ünïcödé façade naïve résumé. ", 40),
+		strings.Repeat("中文句子没有空格。", 80),
+		strings.Repeat("emoji 🚀🔥 mixed with text, v2.0 and e.g. more ", 30),
+	} {
+		f.Add(seed)
+	}
+
+	tc := &TextChunker{
+		Maxsize:      32,
+		Minsize:      1,
+		ChunkOverlap: 0.3,
+	}
+	f.Fuzz(func(t *testing.T, text string) {
+		if !utf8.ValidString(text) {
+			t.Skip()
+		}
+		chunks, err := tc.Chunk(context.Background(), "https://example.com", text)
+		if err != nil {
+			t.Fatalf("Chunk() failed: %v", err)
+		}
+		for _, c := range chunks {
+			if !utf8.ValidString(c.Content) {
+				t.Fatalf("Chunk() produced invalid UTF-8 %q", c.Content)
+			}
+		}
+	})
+}
+
+func stripSpace(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, s)
+}
@@ -0,0 +1,357 @@
+package chunk
+
+import (
+	"context"
+	"log"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// sectionSeparator joins heading titles into a section path
+const sectionSeparator = " > "
+
+// StructuredChunker splits Markdown or HTML along its heading hierarchy.
+// Code blocks and tables are never split, and every chunk is prefixed with
+// its section path, e.g. "Install > Linux > Arch".
+type StructuredChunker struct {
+	Maxsize int
+	Minsize int
+	// Tokenizer measures sizes in tokens; nil estimates 4 bytes per token
+	Tokenizer Tokenizer
+}
+
+func NewStructuredChunker(maxsize int, minsize int, tok Tokenizer) Chunker {
+	return &StructuredChunker{
+		Maxsize:   maxsize,
+		Minsize:   minsize,
+		Tokenizer: tok,
+	}
+}
+
+type blockKind int
+
+const (
+	blockText blockKind = iota
+	blockHeading
+	blockCode
+	blockTable
+)
+
+// block is a unit of a document that chunking never splits, apart from
+// oversized text blocks
+type block struct {
+	kind  blockKind
+	level int
+	text  string
+}
+
+func (sc *StructuredChunker) Chunk(ctx context.Context, link string, content string) ([]Chunk, error) {
+	if strings.TrimSpace(content) == "" {
+		return []Chunk{}, nil
+	}
+
+	var blocks []block
+	source := content
+	if looksLikeHTML(content) {
+		blocks, source = parseHTMLBlocks(content)
+	} else {
+		blocks = parseMarkdownBlocks(content)
+	}
+
+	b := &sectionBuilder{sc: sc, link: link}
+	var headings []string
+	for _, blk := range blocks {
+		if blk.kind != blockHeading {
+			b.add(headings, blk)
+			continue
+		}
+		// Drop headings at this level or deeper, then push the new one. The
+		// slice is copied since the builder may still hold the old one.
+		next := slices.Clone(headings[:min(blk.level-1, len(headings))])
+		for len(next) < blk.level-1 {
+			next = append(next, "")
+		}
+		headings = append(next, blk.text)
+	}
+	b.flush(true)
+	annotate(b.chunks, source)
+
+	log.Printf("structured chunk succeeded with %v results", len(b.chunks))
+	return b.chunks, nil
+}
+
+func (sc *StructuredChunker) tokens(content string) int {
+	if sc.Tokenizer == nil {
+		return approxTokenizer{}.Count(content)
+	}
+	return sc.Tokenizer.Count(content)
+}
+
+// sectionBuilder packs consecutive blocks of a section into chunks
+type sectionBuilder struct {
+	sc      *StructuredChunker
+	link    string
+	section []string
+	parts   []string
+	chunks  []Chunk
+}
+
+func (b *sectionBuilder) add(headings []string, blk block) {
+	if !sameSection(b.section, headings) {
+		// Short sections merge into the next one under their common parent
+		if b.flush(false) {
+			b.section = headings
+		} else {
+			b.section = commonSection(b.section, headings)
+		}
+	}
+
+	prefix := b.prefix()
+	test := append(append([]string{}, b.parts...), blk.text)
+	if b.sc.tokens(prefix+strings.Join(test, "\n\n")) <= b.sc.Maxsize {
+		b.parts = test
+		return
+	}
+
+	b.flush(true)
+	b.section = headings
+	prefix = b.prefix()
+
+	if blk.kind == blockText && b.sc.tokens(prefix+blk.text) > b.sc.Maxsize {
+		// Oversized prose is split by sentences; code and tables stay whole
+		tc := &TextChunker{
+			Maxsize:   max(b.sc.Maxsize-b.sc.tokens(prefix), 1),
+			Minsize:   0,
+			Tokenizer: b.sc.Tokenizer,
+		}
+		idx := 0
+		for _, c := range tc.chunkBySentences(blk.text, b.link, &idx) {
+			b.parts = []string{c.Content}
+			b.flush(true)
+		}
+		return
+	}
+	b.parts = []string{blk.text}
+}
+
+// flush emits the pending chunk. Unless force is set, a chunk under Minsize
+// is kept pending. It reports whether nothing is pending afterwards.
+func (b *sectionBuilder) flush(force bool) bool {
+	if len(b.parts) == 0 {
+		return true
+	}
+
+	content := b.prefix() + strings.Join(b.parts, "\n\n")
+	tokenCount := b.sc.tokens(content)
+	if tokenCount < b.sc.Minsize && !force {
+		return false
+	}
+
+	b.chunks = append(b.chunks, Chunk{
+		Link:       b.link,
+		Content:    strings.TrimSpace(content),
+		TokenCount: tokenCount,
+		Section:    sectionPath(b.section),
+	})
+	b.parts = nil
+	return true
+}
+
+func (b *sectionBuilder) prefix() string {
+	if p := sectionPath(b.section); p != "" {
+		return p + "\n\n"
+	}
+	return ""
+}
+
+func sectionPath(headings []string) string {
+	var parts []string
+	for _, h := range headings {
+		if h != "" {
+			parts = append(parts, h)
+		}
+	}
+	return strings.Join(parts, sectionSeparator)
+}
+
+func sameSection(a []string, b []string) bool {
+	return len(a) == len(b) && sectionPath(a) == sectionPath(b)
+}
+
+func commonSection(a []string, b []string) []string {
+	n := 0
+	for n < len(a) && n < len(b) && a[n] == b[n] {
+		n++
+	}
+	return a[:n:n]
+}
+
+func looksLikeHTML(content string) bool {
+	trimmed := strings.TrimSpace(content)
+	return strings.HasPrefix(trimmed, "<") && strings.Contains(trimmed, "</")
+}
+
+var (
+	atxHeading = regexp.MustCompile(`^(#{1,6})\s+(.+?)\s*#*\s*$`)
+	codeFence  = regexp.MustCompile("^(```|~~~)")
+)
+
+func parseMarkdownBlocks(content string) []block {
+	var blocks []block
+	var para []string
+	flushPara := func() {
+		if text := strings.TrimSpace(strings.Join(para, "\n")); text != "" {
+			blocks = append(blocks, block{kind: blockText, text: text})
+		}
+		para = nil
+	}
+
+	lines := strings.Split(content, "\n")
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimRight(lines[i], " \t\r")
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case codeFence.MatchString(trimmed):
+			flushPara()
+			fence := codeFence.FindString(trimmed)
+			code := []string{line}
+			for i++; i < len(lines); i++ {
+				code = append(code, strings.TrimRight(lines[i], "\r"))
+				if strings.HasPrefix(strings.TrimSpace(lines[i]), fence) {
+					break
+				}
+			}
+			blocks = append(blocks, block{kind: blockCode, text: strings.Join(code, "\n")})
+		case atxHeading.MatchString(trimmed):
+			flushPara()
+			m := atxHeading.FindStringSubmatch(trimmed)
+			blocks = append(blocks, block{kind: blockHeading, level: len(m[1]), text: m[2]})
+		case strings.HasPrefix(trimmed, "|"):
+			flushPara()
+			table := []string{trimmed}
+			for i+1 < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i+1]), "|") {
+				i++
+				table = append(table, strings.TrimSpace(lines[i]))
+			}
+			blocks = append(blocks, block{kind: blockTable, text: strings.Join(table, "\n")})
+		case len(para) == 1 && trimmed != "" && strings.Trim(trimmed, "=") == "":
+			// Setext level 1 heading
+			blocks = append(blocks, block{kind: blockHeading, level: 1, text: strings.TrimSpace(para[0])})
+			para = nil
+		case len(para) == 1 && len(trimmed) >= 2 && strings.Trim(trimmed, "-") == "":
+			// Setext level 2 heading
+			blocks = append(blocks, block{kind: blockHeading, level: 2, text: strings.TrimSpace(para[0])})
+			para = nil
+		case trimmed == "":
+			flushPara()
+		default:
+			para = append(para, line)
+		}
+	}
+	flushPara()
+
+	return blocks
+}
+
+// chromeElements carry page chrome rather than content. It copies
+// chromeElements in internal/scrape, which strips them from scraped pages,
+// since chunking doesn't depend on the scraper; keep the two in sync.
+const chromeElements = "script, style, noscript, template, nav, header, footer, aside, form, iframe, svg"
+
+// parseHTMLBlocks returns the blocks of an HTML document and its visible text
+func parseHTMLBlocks(content string) ([]block, string) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(content))
+	if err != nil {
+		return parseMarkdownBlocks(content), content
+	}
+	root := doc.Find("body")
+	if root.Length() == 0 {
+		root = doc.Selection
+	}
+	root.Find(chromeElements).Remove()
+
+	var blocks []block
+	var inline []string
+	flushInline := func() {
+		if text := strings.Join(strings.Fields(strings.Join(inline, " ")), " "); text != "" {
+			blocks = append(blocks, block{kind: blockText, text: text})
+		}
+		inline = nil
+	}
+
+	var walk func(s *goquery.Selection)
+	walk = func(s *goquery.Selection) {
+		s.Contents().Each(func(_ int, n *goquery.Selection) {
+			name := goquery.NodeName(n)
+			switch name {
+			case "#text":
+				inline = append(inline, n.Text())
+			case "h1", "h2", "h3", "h4", "h5", "h6":
+				flushInline()
+				if text := strings.Join(strings.Fields(n.Text()), " "); text != "" {
+					blocks = append(blocks, block{kind: blockHeading, level: int(name[1] - '0'), text: text})
+				}
+			case "pre":
+				flushInline()
+				if code := strings.Trim(n.Text(), "\n"); strings.TrimSpace(code) != "" {
+					blocks = append(blocks, block{kind: blockCode, text: "```\n" + code + "\n```"})
+				}
+			case "table":
+				flushInline()
+				if table := htmlTable(n); table != "" {
+					blocks = append(blocks, block{kind: blockTable, text: table})
+				}
+			case "li":
+				flushInline()
+				if n.Find("pre, table, ul, ol").Length() > 0 {
+					walk(n)
+				} else {
+					inline = append(inline, "-", n.Text())
+				}
+				flushInline()
+			case "p", "blockquote", "dd", "dt", "figcaption", "div", "section", "article", "main", "ul", "ol", "dl":
+				flushInline()
+				walk(n)
+				flushInline()
+			case "br":
+				flushInline()
+			default:
+				walk(n)
+			}
+		})
+	}
+	walk(root)
+	flushInline()
+
+	return blocks, strings.Join(strings.Fields(root.Text()), " ")
+}
+
+// htmlText renders an HTML document as plain text, a paragraph per block,
+// for the chunkers that don't parse markup
+func htmlText(content string) string {
+	blocks, _ := parseHTMLBlocks(content)
+	texts := make([]string, len(blocks))
+	for i, b := range blocks {
+		texts[i] = b.text
+	}
+	return strings.Join(texts, "\n\n")
+}
+
+// htmlTable renders a table as Markdown-style rows
+func htmlTable(table *goquery.Selection) string {
+	var rows []string
+	table.Find("tr").Each(func(_ int, tr *goquery.Selection) {
+		var cells []string
+		tr.Find("th, td").Each(func(_ int, td *goquery.Selection) {
+			cells = append(cells, strings.Join(strings.Fields(td.Text()), " "))
+		})
+		if len(cells) > 0 {
+			rows = append(rows, "| "+strings.Join(cells, " | ")+" |")
+		}
+	})
+	return strings.Join(rows, "\n")
+}
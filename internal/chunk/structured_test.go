@@ -0,0 +1,71 @@
+package chunk_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ary82/goseek/internal/chunk"
+)
+
+func TestStructuredChunker_Chunk(t *testing.T) {
+	para := strings.Repeat("Words about the topic at hand. ", 6)
+	code := "```sh\n" + strings.Repeat("pacman -S goseek\n", 20) + "```"
+
+	tests := []struct {
+		name         string
+		content      string
+		wantSections []string
+		wantWhole    string
+	}{
+		{
+			name: "test markdown",
+			content: "# Install\n\n" + para + "\n\n## Linux\n\n### Arch\n\n" + para + "\n\n" + code +
+				"\n\n## Windows\n\n| Step | Command |\n|---|---|\n| 1 | winget install goseek |\n\n" + para,
+			wantSections: []string{"Install", "Install > Linux > Arch", "Install > Linux > Arch", "Install > Windows"},
+			wantWhole:    code,
+		},
+		{
+			name: "test html",
+			content: "<html><body><nav>Home | Docs</nav><h1>Install</h1><p>" + para + "</p>" +
+				"<h2>Linux</h2><h3>Arch</h3><p>" + para + "</p><pre>" + strings.Repeat("pacman -S goseek\n", 20) + "</pre>" +
+				"<h2>Windows</h2><table><tr><th>Step</th><th>Command</th></tr><tr><td>1</td><td>winget install goseek</td></tr></table>" +
+				"<ul><li>" + para + "</li></ul></body></html>",
+			wantSections: []string{"Install", "Install > Linux > Arch", "Install > Linux > Arch", "Install > Windows"},
+			wantWhole:    "| Step | Command |\n| 1 | winget install goseek |",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sc := &chunk.StructuredChunker{
+				Maxsize: 96,
+				Minsize: 16,
+			}
+			got, gotErr := sc.Chunk(context.Background(), "https://example.com", tt.content)
+			if gotErr != nil {
+				t.Fatalf("Chunk() failed: %v", gotErr)
+			}
+
+			var sections []string
+			whole := false
+			for _, c := range got {
+				sections = append(sections, c.Section)
+				if !strings.HasPrefix(c.Content, c.Section+"\n\n") {
+					t.Errorf("Chunk() content not prefixed with section %q: %q", c.Section, c.Content)
+				}
+				if strings.Contains(c.Content, tt.wantWhole) {
+					whole = true
+				}
+				if strings.Contains(c.Content, "Home | Docs") {
+					t.Errorf("Chunk() kept page chrome: %q", c.Content)
+				}
+			}
+			if strings.Join(sections, ",") != strings.Join(tt.wantSections, ",") {
+				t.Errorf("Chunk() sections = %q, want %q", sections, tt.wantSections)
+			}
+			if !whole {
+				t.Errorf("Chunk() split block %q", tt.wantWhole)
+			}
+		})
+	}
+}
@@ -10,13 +10,16 @@ type TextChunker struct {
 	Maxsize      int
 	Minsize      int
 	ChunkOverlap float64
+	// Tokenizer measures sizes in tokens; nil estimates 4 bytes per token
+	Tokenizer Tokenizer
 }
 
-func NewTextChunker(maxsize int, minsize int, overlap float64) Chunker {
+func NewTextChunker(maxsize int, minsize int, overlap float64, tok Tokenizer) Chunker {
 	return &TextChunker{
 		Maxsize:      maxsize,
 		Minsize:      minsize,
 		ChunkOverlap: overlap,
+		Tokenizer:    tok,
 	}
 }
 
@@ -40,14 +43,14 @@ func (tc *TextChunker) Chunk(ctx context.Context, link string, content string) (
 			testChunk = paragraph
 		}
 
-		testTokenCount := simpleTokenCount(testChunk)
+		testTokenCount := tc.tokens(testChunk)
 
 		if testTokenCount <= tc.Maxsize {
 			// Paragraph fits, add it
 			currentChunk = testChunk
 		} else if currentChunk != "" {
 			// Current chunk is full, save it and start new one
-			tokenCount := simpleTokenCount(currentChunk)
+			tokenCount := tc.tokens(currentChunk)
 			if tokenCount >= tc.Minsize {
 				chunks = append(chunks, Chunk{
 					Content:    strings.TrimSpace(currentChunk),
@@ -59,14 +62,14 @@ func (tc *TextChunker) Chunk(ctx context.Context, link string, content string) (
 
 			// Start new chunk with overlap
 			overlapText := tc.getOverlapText(currentChunk)
-			if overlapText != "" && simpleTokenCount(overlapText+"\n\n"+paragraph) <= tc.Maxsize {
+			if overlapText != "" && tc.tokens(overlapText+"\n\n"+paragraph) <= tc.Maxsize {
 				currentChunk = overlapText + "\n\n" + paragraph
 			} else {
 				currentChunk = paragraph
 			}
 
 			// If single paragraph is still too large, split by sentences
-			if simpleTokenCount(currentChunk) > tc.Maxsize {
+			if tc.tokens(currentChunk) > tc.Maxsize {
 				sentenceChunks := tc.chunkBySentences(paragraph, link, &chunkIndex)
 				chunks = append(chunks, sentenceChunks...)
 				currentChunk = ""
@@ -80,7 +83,7 @@ func (tc *TextChunker) Chunk(ctx context.Context, link string, content string) (
 
 	// Add final chunk if it exists and meets minimum size
 	if currentChunk != "" {
-		tokenCount := simpleTokenCount(currentChunk)
+		tokenCount := tc.tokens(currentChunk)
 		if tokenCount >= tc.Minsize {
 			chunks = append(chunks, Chunk{
 				Content:    strings.TrimSpace(currentChunk),
@@ -90,6 +93,8 @@ func (tc *TextChunker) Chunk(ctx context.Context, link string, content string) (
 		}
 	}
 
+	annotate(chunks, content)
+
 	log.Printf("chunk succeeded with %v results", len(chunks))
 	return chunks, nil
 }
@@ -109,13 +114,13 @@ func (tc *TextChunker) chunkBySentences(text string, link string, chunkIndex *in
 			testChunk = sentence
 		}
 
-		testTokenCount := simpleTokenCount(testChunk)
+		testTokenCount := tc.tokens(testChunk)
 
 		if testTokenCount <= tc.Maxsize {
 			currentChunk = testChunk
 		} else if currentChunk != "" {
 			// Save current chunk
-			tokenCount := simpleTokenCount(currentChunk)
+			tokenCount := tc.tokens(currentChunk)
 			if tokenCount >= tc.Minsize {
 				chunks = append(chunks, Chunk{
 					Content:    strings.TrimSpace(currentChunk),
@@ -127,7 +132,7 @@ func (tc *TextChunker) chunkBySentences(text string, link string, chunkIndex *in
 
 			// Start new chunk with overlap
 			overlapText := tc.getOverlapText(currentChunk)
-			if overlapText != "" && simpleTokenCount(overlapText+" "+sentence) <= tc.Maxsize {
+			if overlapText != "" && tc.tokens(overlapText+" "+sentence) <= tc.Maxsize {
 				currentChunk = overlapText + " " + sentence
 			} else {
 				currentChunk = sentence
@@ -140,7 +145,7 @@ func (tc *TextChunker) chunkBySentences(text string, link string, chunkIndex *in
 
 	// Add final sentence chunk
 	if currentChunk != "" {
-		tokenCount := simpleTokenCount(currentChunk)
+		tokenCount := tc.tokens(currentChunk)
 		if tokenCount >= tc.Minsize {
 			chunks = append(chunks, Chunk{
 				Content:    strings.TrimSpace(currentChunk),
@@ -169,22 +174,7 @@ func (tc *TextChunker) splitIntoParagraphs(text string) []string {
 }
 
 func (tc *TextChunker) splitIntoSentences(text string) []string {
-	var sentences []string
-	var currentSentence string
-
-	for _, r := range text {
-		currentSentence += string(r)
-		// End of sentence
-		if r == '.' || r == '!' || r == '?' {
-			sentences = append(sentences, strings.TrimSpace(currentSentence))
-			currentSentence = ""
-		}
-	}
-
-	if currentSentence != "" {
-		sentences = append(sentences, strings.TrimSpace(currentSentence))
-	}
-	return sentences
+	return splitSentences(text)
 }
 
 // getOverlapText extracts overlap text from the end of a chunk
@@ -206,7 +196,7 @@ func (tc *TextChunker) getOverlapText(text string) string {
 			testText = sentences[i] + " " + overlapText
 		}
 
-		if simpleTokenCount(testText) <= overlapTokens {
+		if tc.tokens(testText) <= overlapTokens {
 			overlapText = testText
 		} else {
 			break
@@ -217,7 +207,7 @@ func (tc *TextChunker) getOverlapText(text string) string {
 	if overlapText == "" {
 		overlapChars := overlapTokens * 4
 		if len(text) > overlapChars {
-			overlapText = text[len(text)-overlapChars:]
+			overlapText = tailRunes(text, overlapChars)
 			// Try to start at a word boundary
 			if spaceIdx := strings.Index(overlapText, " "); spaceIdx != -1 {
 				overlapText = overlapText[spaceIdx+1:]
@@ -230,6 +220,10 @@ func (tc *TextChunker) getOverlapText(text string) string {
 	return overlapText
 }
 
-func simpleTokenCount(content string) int {
-	return (len(content) / 4)
+// tokens counts with tc.Tokenizer, falling back to the 4 bytes per token estimate
+func (tc *TextChunker) tokens(content string) int {
+	if tc.Tokenizer == nil {
+		return approxTokenizer{}.Count(content)
+	}
+	return tc.Tokenizer.Count(content)
 }
@@ -27,7 +27,7 @@ func TestTokenChunker_Chunk(t *testing.T) {
 				Minsize:      256,
 				ChunkOverlap: 0.1,
 			}
-			got, gotErr := tc.Chunk(context.Background(), tt.content)
+			got, gotErr := tc.Chunk(context.Background(), "https://www.pnnl.gov/explainer-articles/nanomaterials", tt.content)
 			if gotErr != nil {
 				if !tt.wantErr {
 					t.Errorf("Chunk() failed: %v", gotErr)
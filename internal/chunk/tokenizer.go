@@ -0,0 +1,152 @@
+package chunk
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// approxTokenizer estimates 4 bytes per token
+type approxTokenizer struct{}
+
+func NewApproxTokenizer() Tokenizer {
+	return approxTokenizer{}
+}
+
+func (approxTokenizer) Count(text string) int {
+	return len(text) / 4
+}
+
+// bpeTokenizer is a byte-level BPE tokenizer using tiktoken-style merge ranks
+type bpeTokenizer struct {
+	ranks map[string]int
+	pre   *regexp.Regexp
+
+	mu    sync.Mutex
+	cache map[string]int
+}
+
+// pretokenizePattern approximates the cl100k_base split pattern; Go's regexp
+// has no lookahead, so trailing whitespace is kept as its own piece
+const pretokenizePattern = `(?i:'s|'t|'re|'ve|'m|'ll|'d)|[^\r\n\p{L}\p{N}]?\p{L}+|\p{N}{1,3}| ?[^\s\p{L}\p{N}]+[\r\n]*|\s*[\r\n]+|\s+`
+
+// maxCachedPieces bounds the piece count cache
+const maxCachedPieces = 1 << 16
+
+// NewBPETokenizer loads a vocabulary in the tiktoken format: one
+// "<base64 token> <rank>" pair per line, as shipped for cl100k_base and
+// o200k_base
+func NewBPETokenizer(path string) (Tokenizer, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening vocab: %w", err)
+	}
+	defer f.Close()
+
+	ranks := make(map[string]int)
+	sc := bufio.NewScanner(f)
+	line := 0
+	for sc.Scan() {
+		line++
+		fields := strings.Fields(sc.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("vocab line %d: expected token and rank", line)
+		}
+		tok, err := base64.StdEncoding.DecodeString(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("vocab line %d: %w", line, err)
+		}
+		rank, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("vocab line %d: %w", line, err)
+		}
+		ranks[string(tok)] = rank
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("error reading vocab: %w", err)
+	}
+	if len(ranks) == 0 {
+		return nil, fmt.Errorf("vocab %s is empty", path)
+	}
+
+	return &bpeTokenizer{
+		ranks: ranks,
+		pre:   regexp.MustCompile(pretokenizePattern),
+		cache: make(map[string]int),
+	}, nil
+}
+
+func (b *bpeTokenizer) Count(text string) int {
+	n := 0
+	for _, piece := range b.pre.FindAllString(text, -1) {
+		n += b.countPiece(piece)
+	}
+	return n
+}
+
+func (b *bpeTokenizer) countPiece(piece string) int {
+	if _, ok := b.ranks[piece]; ok {
+		return 1
+	}
+
+	b.mu.Lock()
+	n, ok := b.cache[piece]
+	b.mu.Unlock()
+	if ok {
+		return n
+	}
+
+	n = len(b.merge(piece))
+
+	b.mu.Lock()
+	if len(b.cache) >= maxCachedPieces {
+		clear(b.cache)
+	}
+	b.cache[piece] = n
+	b.mu.Unlock()
+	return n
+}
+
+// merge repeatedly joins the adjacent pair with the lowest rank, as in
+// tiktoken's byte_pair_merge. It returns the token boundaries.
+func (b *bpeTokenizer) merge(piece string) []int {
+	// bounds[i] is the start of part i; the last entry is len(piece)
+	bounds := make([]int, 0, len(piece)+1)
+	for i := 0; i <= len(piece); i++ {
+		bounds = append(bounds, i)
+	}
+
+	rankOf := func(i int) int {
+		if i+2 >= len(bounds) {
+			return math.MaxInt
+		}
+		if r, ok := b.ranks[piece[bounds[i]:bounds[i+2]]]; ok {
+			return r
+		}
+		return math.MaxInt
+	}
+
+	for len(bounds) > 2 {
+		best, bestRank := -1, math.MaxInt
+		for i := 0; i+2 < len(bounds); i++ {
+			if r := rankOf(i); r < bestRank {
+				best, bestRank = i, r
+			}
+		}
+		if best == -1 {
+			break
+		}
+		bounds = append(bounds[:best+1], bounds[best+2:]...)
+	}
+
+	return bounds[:len(bounds)-1]
+}
@@ -0,0 +1,74 @@
+package chunk_test
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ary82/goseek/internal/chunk"
+)
+
+// writeVocab writes a tiktoken-style vocab with every single byte plus merges
+func writeVocab(t *testing.T, merges ...string) string {
+	t.Helper()
+
+	var b strings.Builder
+	for i := range 256 {
+		fmt.Fprintf(&b, "%s %d\n", base64.StdEncoding.EncodeToString([]byte{byte(i)}), i)
+	}
+	for i, m := range merges {
+		fmt.Fprintf(&b, "%s %d\n", base64.StdEncoding.EncodeToString([]byte(m)), 256+i)
+	}
+
+	path := filepath.Join(t.TempDir(), "test.tiktoken")
+	if err := os.WriteFile(path, []byte(b.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestBPETokenizer_Count(t *testing.T) {
+	vocab := writeVocab(t, "he", "the", " t", " the", "in", "ing")
+
+	tests := []struct {
+		name string
+		text string
+		want int
+	}{
+		{name: "test empty", text: "", want: 0},
+		{name: "test whole word", text: "the", want: 1},
+		{name: "test leading space", text: "the the", want: 2},
+		{name: "test partial merges", text: "thing", want: 3},
+		{name: "test unknown bytes", text: "xyz", want: 3},
+		{name: "test digits split by three", text: "12345", want: 5},
+		{name: "test multibyte", text: "日本", want: 6},
+	}
+	tok, err := chunk.NewBPETokenizer(vocab)
+	if err != nil {
+		t.Fatalf("NewBPETokenizer() failed: %v", err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tok.Count(tt.text); got != tt.want {
+				t.Errorf("Count(%q) = %v, want %v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewBPETokenizer_errors(t *testing.T) {
+	dir := t.TempDir()
+	bad := filepath.Join(dir, "bad.tiktoken")
+	if err := os.WriteFile(bad, []byte("not-base64! 1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{filepath.Join(dir, "missing"), bad} {
+		if _, err := chunk.NewBPETokenizer(path); err == nil {
+			t.Errorf("NewBPETokenizer(%v) succeeded unexpectedly", path)
+		}
+	}
+}
@@ -5,18 +5,40 @@ const (
 	UA         = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/119.0.0.0 Safari/537.36"
 )
 
-const PROMPT = `You are an expert summarizing the answers based on the provided contents.
+const RERANK_PROMPT = `You are ranking search results by how well they answer a question.
 
-	Given the context as a sequence of references with a reference id in the format of a leading [x], please answer the following question:
+Question: {{ %s }}
 
-{{ %s }}
+Each passage below starts with its number in the format [n]. Reply with the numbers of the passages
+ordered from most to least useful for answering the question, separated by commas, e.g. "3, 1, 2".
+Leave out passages that are irrelevant. Reply with the numbers only.
 
-In the answer, use format [link1], [link2], ..., [n] to mention the sources where the reference is used. 
-	At the end of the answer, also give the legend, specifying which number represents which link. Don't give the legend of links that are not used for the answer and merge the duplicates, both in content and legend. It should be coherent
+Passages:
+%s
+`
+
+const GROUNDING_PROMPT = `You are checking whether an answer is supported by its sources.
+
+Each source below starts with its number in the format [n]. Each claim starts with its number in the
+format (k) and ends with the numbers of the sources it cites; a claim citing none may use any source.
+For every claim, reply with one line "k: yes" if its sources state or directly imply it, or "k: no"
+otherwise. Reply with these lines only.
+
+Sources:
+%s
+
+Claims:
+%s
+`
+
+const CONDENSE_PROMPT = `You are rewriting a follow-up question into a web search query.
 
-Please create the answer strictly related to the context.
-	If the context has no information about the query, please write "No related information found in the context."
+Given the conversation below and a follow-up question, rewrite the follow-up into a standalone search query that can be
+understood without the conversation, e.g. "what about on Windows?" after a question on installing Go becomes
+"how to install Go on Windows". Keep search operators such as site:go.dev or last:year as they are. If the follow-up
+is already standalone, repeat it unchanged. Reply with the query only.
 
-Here is the context:
-{{ %s }}
+Conversation:
+%s
+Follow-up question: %s
 `
@@ -0,0 +1,38 @@
+package conversation
+
+import (
+	"context"
+	"sync"
+
+	"github.com/ary82/goseek/internal/retrieval"
+)
+
+// Turn is a question of a conversation and its answer
+type Turn struct {
+	// Query is the question as asked, and Standalone the query it was
+	// condensed into and searched for
+	Query      string
+	Standalone string
+	Answer     string
+	Mode       string
+}
+
+// Conversation is the thread of questions asked in one chat. It is safe
+// for concurrent use.
+type Conversation struct {
+	ID string
+	// ReuseChunks keeps the chunks of every query in one namespace, so that
+	// follow-ups also retrieve from the sources of earlier questions
+	ReuseChunks bool
+
+	mu        sync.Mutex
+	turns     []Turn
+	namespace string
+	keywords  *retrieval.BM25Index
+}
+
+// Condenser rewrites a follow-up question into a query that can be searched
+// without the conversation before it
+type Condenser interface {
+	Condense(ctx context.Context, history []Turn, followUp string) (string, error)
+}
@@ -0,0 +1,62 @@
+package conversation
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/ary82/goseek/internal/constants"
+	"github.com/ary82/goseek/internal/llm"
+)
+
+type llmCondenser struct {
+	llm llm.LLM
+}
+
+// NewLLMCondenser asks the LLM to rewrite follow-ups using the history. A
+// question without history is returned as is.
+func NewLLMCondenser(l llm.LLM) Condenser {
+	return &llmCondenser{
+		llm: l,
+	}
+}
+
+func (lc *llmCondenser) Condense(ctx context.Context, history []Turn, followUp string) (string, error) {
+	if len(history) == 0 {
+		return followUp, nil
+	}
+
+	var thread strings.Builder
+	for _, t := range History(history) {
+		fmt.Fprintf(&thread, "User: %s\nAssistant: %s\n\n", t.Query, t.Answer)
+	}
+
+	response, err := lc.llm.GenerateContent(ctx, fmt.Sprintf(constants.CONDENSE_PROMPT, thread.String(), followUp))
+	if err != nil {
+		return "", fmt.Errorf("error condensing question with LLM: %w", err)
+	}
+
+	query := cleanQuery(*response)
+	if query == "" {
+		return followUp, nil
+	}
+	log.Printf("condensed %q into %q", followUp, query)
+	return query, nil
+}
+
+// cleanQuery takes the first line of an LLM reply, without the label or
+// quotes the model may add around it
+func cleanQuery(response string) string {
+	for _, line := range strings.Split(response, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if label, rest, ok := strings.Cut(line, ":"); ok && strings.EqualFold(strings.TrimSpace(label), "query") {
+			line = strings.TrimSpace(rest)
+		}
+		return strings.Trim(line, "\"'`“”")
+	}
+	return ""
+}
@@ -0,0 +1,81 @@
+package conversation
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/ary82/goseek/internal/prompt"
+	"github.com/ary82/goseek/internal/retrieval"
+	"github.com/google/uuid"
+)
+
+// historyAnswerLen bounds the length of an earlier answer in bytes when it
+// is given to a prompt
+const historyAnswerLen = 1000
+
+func New(id string, reuseChunks bool) *Conversation {
+	return &Conversation{
+		ID:          id,
+		ReuseChunks: reuseChunks,
+	}
+}
+
+// Add appends a turn to the conversation
+func (c *Conversation) Add(t Turn) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.turns = append(c.turns, t)
+}
+
+// Turns returns the turns so far, oldest first
+func (c *Conversation) Turns() []Turn {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Turn(nil), c.turns...)
+}
+
+// Last returns the n latest turns, oldest first
+func (c *Conversation) Last(n int) []Turn {
+	turns := c.Turns()
+	return turns[max(0, len(turns)-n):]
+}
+
+// citationMarker matches the citation markers of an answer, whose numbers
+// only mean something next to that answer's sources, and subscripts such as
+// arr[0], which are kept
+var citationMarker = regexp.MustCompile(`\w\[\d+(?:\s*,\s*\d+)*\]|\s?\[\d+(?:\s*,\s*\d+)*\]`)
+
+// History converts turns for a prompt, with the answers shortened and
+// without their citation markers
+func History(turns []Turn) []prompt.Turn {
+	history := make([]prompt.Turn, 0, len(turns))
+	for _, t := range turns {
+		answer := citationMarker.ReplaceAllStringFunc(t.Answer, func(m string) string {
+			if !strings.HasPrefix(strings.TrimSpace(m), "[") {
+				return m
+			}
+			return ""
+		})
+		if len(answer) > historyAnswerLen {
+			answer = strings.ToValidUTF8(answer[:historyAnswerLen], "") + "…"
+		}
+		history = append(history, prompt.Turn{Query: t.Query, Answer: answer})
+	}
+	return history
+}
+
+// Chunks returns the namespace and keyword index the queries of the
+// conversation share, creating them on the first call, or "" and nil when
+// chunks are not reused
+func (c *Conversation) Chunks() (string, *retrieval.BM25Index) {
+	if !c.ReuseChunks {
+		return "", nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.namespace == "" {
+		c.namespace = uuid.NewString()
+		c.keywords = retrieval.NewBM25Index()
+	}
+	return c.namespace, c.keywords
+}
@@ -0,0 +1,117 @@
+package conversation_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ary82/goseek/internal/conversation"
+	"github.com/ary82/goseek/internal/prompt"
+)
+
+type llmMock struct {
+	response string
+	err      error
+	calls    int
+}
+
+func (m *llmMock) GenerateContent(ctx context.Context, prompt string) (*string, error) {
+	m.calls++
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &m.response, nil
+}
+
+func TestConversation_Last(t *testing.T) {
+	c := conversation.New("chat", false)
+	for _, q := range []string{"a", "b", "c"} {
+		c.Add(conversation.Turn{Query: q})
+	}
+
+	tests := []struct {
+		name string
+		n    int
+		want []string
+	}{
+		{name: "test fewer", n: 2, want: []string{"b", "c"}},
+		{name: "test more", n: 5, want: []string{"a", "b", "c"}},
+		{name: "test none", n: 0, want: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := []string{}
+			for _, turn := range c.Last(tt.n) {
+				got = append(got, turn.Query)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Last() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHistory(t *testing.T) {
+	long := strings.Repeat("x", 1200)
+	got := conversation.History([]conversation.Turn{
+		{Query: "how to install go", Standalone: "how to install go", Answer: "Download it [1] and set args[0] [1, 2]."},
+		{Query: "more", Answer: long},
+	})
+	want := []prompt.Turn{
+		{Query: "how to install go", Answer: "Download it and set args[0]."},
+		{Query: "more", Answer: long[:1000] + "…"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("History() = %v, want %v", got, want)
+	}
+}
+
+func TestConversation_Chunks(t *testing.T) {
+	if ns, kw := conversation.New("chat", false).Chunks(); ns != "" || kw != nil {
+		t.Errorf("Chunks() without reuse = %q, %v", ns, kw)
+	}
+
+	c := conversation.New("chat", true)
+	ns, kw := c.Chunks()
+	ns2, kw2 := c.Chunks()
+	if ns == "" || kw == nil || ns != ns2 || kw != kw2 {
+		t.Errorf("Chunks() = %q, %p then %q, %p, want the same namespace and index", ns, kw, ns2, kw2)
+	}
+}
+
+func TestLLMCondenser_Condense(t *testing.T) {
+	history := []conversation.Turn{{Query: "how to install go on linux", Answer: "Use the tarball [1]."}}
+
+	tests := []struct {
+		name      string
+		history   []conversation.Turn
+		response  string
+		err       error
+		want      string
+		wantCalls int
+		wantErr   bool
+	}{
+		{name: "test no history", want: "what about on windows?"},
+		{name: "test condensed", history: history, response: "how to install go on windows\n", want: "how to install go on windows", wantCalls: 1},
+		{name: "test labelled", history: history, response: "Query: \"how to install go on windows\"", want: "how to install go on windows", wantCalls: 1},
+		{name: "test empty reply", history: history, response: "  \n", want: "what about on windows?", wantCalls: 1},
+		{name: "test llm error", history: history, err: errors.New("quota exceeded"), wantCalls: 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &llmMock{response: tt.response, err: tt.err}
+			got, err := conversation.NewLLMCondenser(m).Condense(context.Background(), tt.history, "what about on windows?")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Condense() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Condense() = %q, want %q", got, tt.want)
+			}
+			if m.calls != tt.wantCalls {
+				t.Errorf("Condense() made %d LLM calls, want %d", m.calls, tt.wantCalls)
+			}
+		})
+	}
+}
@@ -0,0 +1,8 @@
+package embed
+
+import "context"
+
+// Embedder turns texts into vectors, one per text, in order
+type Embedder interface {
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
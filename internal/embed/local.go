@@ -0,0 +1,90 @@
+package embed
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"strings"
+	"unicode"
+)
+
+// hashEmbedder is an offline embedder that hashes word unigrams and bigrams
+// into a fixed number of dimensions. It captures lexical overlap only, but
+// needs no model or network.
+type hashEmbedder struct {
+	dims int
+}
+
+func NewHashEmbedder(dims int) (Embedder, error) {
+	if dims <= 0 {
+		return nil, fmt.Errorf("invalid embedding dimensions %d", dims)
+	}
+	return &hashEmbedder{
+		dims: dims,
+	}, nil
+}
+
+func (h *hashEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	out := make([][]float32, len(texts))
+	for i, text := range texts {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		out[i] = h.embed(text)
+	}
+	return out, nil
+}
+
+func (h *hashEmbedder) embed(text string) []float32 {
+	vec := make([]float32, h.dims)
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	for i, w := range words {
+		h.add(vec, w, 1)
+		if i > 0 {
+			h.add(vec, words[i-1]+" "+w, 0.5)
+		}
+	}
+
+	var norm float64
+	for _, v := range vec {
+		norm += float64(v) * float64(v)
+	}
+	if norm > 0 {
+		scale := float32(1 / math.Sqrt(norm))
+		for i := range vec {
+			vec[i] *= scale
+		}
+	}
+	return vec
+}
+
+// add uses the hash's top bit as a sign so collisions tend to cancel out
+func (h *hashEmbedder) add(vec []float32, feature string, weight float32) {
+	f := fnv.New64a()
+	f.Write([]byte(feature))
+	sum := f.Sum64()
+
+	idx := int(sum % uint64(h.dims))
+	if sum>>63 == 1 {
+		weight = -weight
+	}
+	vec[idx] += weight
+}
+
+// Cosine returns the cosine similarity of a and b, or 0 if either is zero
+func Cosine(a []float32, b []float32) float64 {
+	var dot, na, nb float64
+	for i := range min(len(a), len(b)) {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
@@ -0,0 +1,111 @@
+package mode
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Format is the shape of an answer, which decides how it is displayed
+type Format string
+
+const (
+	// FormatText is prose, wrapped to the width of the terminal
+	FormatText Format = "text"
+	// FormatMarkdown has headings, lists and code blocks
+	FormatMarkdown Format = "markdown"
+	// FormatTable is a table, which is not wrapped
+	FormatTable Format = "table"
+)
+
+// Mode is a way of answering a query: the prompt template, how much is
+// gathered for the context and what the answer looks like. Zero sizes keep
+// the pipeline's defaults.
+type Mode struct {
+	Name        string
+	Description string
+	// Template is the prompt template, see prompt.Registry.Select
+	Template string
+	// ContextScale scales the context token budget
+	ContextScale float64
+	// Passages bounds the chunks considered for the context
+	Passages int
+	// Sources is the number of scraped pages after which the remaining
+	// search results are dropped
+	Sources int
+	Format  Format
+	// CodeBoost and DocsBoost weight the hits with code and those from
+	// official documentation, see Mode.Boost
+	CodeBoost float64
+	DocsBoost float64
+}
+
+// Default is the mode of queries that do not ask for one
+const Default = "concise"
+
+// Modes are the available modes, Default first
+var Modes = []Mode{
+	{
+		Name:         "concise",
+		Description:  "a short answer",
+		Template:     "answer",
+		ContextScale: 1,
+		Format:       FormatText,
+	},
+	{
+		Name:         "report",
+		Description:  "a detailed report with sections, from more sources",
+		Template:     "report",
+		ContextScale: 2,
+		Passages:     24,
+		Sources:      10,
+		Format:       FormatMarkdown,
+	},
+	{
+		Name:         "bullets",
+		Description:  "a bullet point summary",
+		Template:     "bullets",
+		ContextScale: 1,
+		Format:       FormatMarkdown,
+	},
+	{
+		Name:         "compare",
+		Description:  "a comparison table",
+		Template:     "compare",
+		ContextScale: 1.5,
+		Passages:     16,
+		Sources:      8,
+		Format:       FormatTable,
+	},
+	{
+		Name:         "code",
+		Description:  "code examples, favouring official documentation",
+		Template:     "code",
+		ContextScale: 1.25,
+		Format:       FormatMarkdown,
+		CodeBoost:    1.5,
+		DocsBoost:    1.5,
+	},
+}
+
+// Parse returns the mode named name, or the Default mode if name is empty
+func Parse(name string) (Mode, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		name = Default
+	}
+	for _, m := range Modes {
+		if m.Name == name {
+			return m, nil
+		}
+	}
+	return Mode{}, fmt.Errorf("unknown mode %q, want one of %s", name, strings.Join(Names(), ", "))
+}
+
+// Names returns the names of the modes, Default first
+func Names() []string {
+	names := make([]string, len(Modes))
+	for i, m := range Modes {
+		names[i] = m.Name
+	}
+	return names
+}
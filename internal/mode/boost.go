@@ -0,0 +1,61 @@
+package mode
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/ary82/goseek/internal/chunk"
+	"github.com/ary82/goseek/internal/retrieval"
+	"github.com/ary82/goseek/internal/vectorstorage"
+)
+
+// Boost returns the factor the weight of a hit is multiplied by in m
+func (m Mode) Boost(h retrieval.Hit) float64 {
+	boost := 1.0
+	if m.CodeBoost > 0 && hasCode(h) {
+		boost *= m.CodeBoost
+	}
+	if m.DocsBoost > 0 && IsDocs(h.Link) {
+		boost *= m.DocsBoost
+	}
+	return boost
+}
+
+// codeLine matches lines that look like code or a shell session
+var codeLine = regexp.MustCompile("(?m)^\\s*(```|\\$ |>>> |func |def |class |import |package |#include)|[;{}]\\s*$")
+
+// hasCode reports whether the hit is from a code page or looks like code
+func hasCode(h retrieval.Hit) bool {
+	if ct, _ := h.Meta[vectorstorage.FieldContentType].(string); ct == chunk.ContentCode {
+		return true
+	}
+	return len(codeLine.FindAllStringIndex(h.Text, 2)) == 2
+}
+
+var (
+	docsHostPrefixes = []string{"docs.", "doc.", "developer.", "developers.", "learn.", "devdocs."}
+	docsHosts        = []string{"pkg.go.dev", "readthedocs.io", "readthedocs.org", "cppreference.com", "javadoc.io"}
+	docsPath         = regexp.MustCompile(`^/(docs?|documentation|reference|manual|api|library|man)(/|$)`)
+)
+
+// IsDocs guesses whether link is official documentation, e.g.
+// docs.python.org or go.dev/doc/
+func IsDocs(link string) bool {
+	u, err := url.Parse(link)
+	if err != nil {
+		return false
+	}
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	for _, p := range docsHostPrefixes {
+		if strings.HasPrefix(host, p) {
+			return true
+		}
+	}
+	for _, h := range docsHosts {
+		if host == h || strings.HasSuffix(host, "."+h) {
+			return true
+		}
+	}
+	return docsPath.MatchString(strings.ToLower(u.Path))
+}
@@ -0,0 +1,100 @@
+package mode_test
+
+import (
+	"testing"
+
+	"github.com/ary82/goseek/internal/mode"
+	"github.com/ary82/goseek/internal/retrieval"
+	"github.com/ary82/goseek/internal/vectorstorage"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{name: "test default", input: "", want: mode.Default},
+		{name: "test mode", input: "report", want: "report"},
+		{name: "test case", input: " Compare ", want: "compare"},
+		{name: "test unknown", input: "poem", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := mode.Parse(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got.Name != tt.want {
+				t.Errorf("Parse() = %v, want %v", got.Name, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsDocs(t *testing.T) {
+	tests := []struct {
+		link string
+		want bool
+	}{
+		{link: "https://docs.python.org/3/library/asyncio.html", want: true},
+		{link: "https://pkg.go.dev/net/http", want: true},
+		{link: "https://go.dev/doc/effective_go", want: true},
+		{link: "https://requests.readthedocs.io/en/latest/", want: true},
+		{link: "https://developer.mozilla.org/en-US/docs/Web", want: true},
+		{link: "https://stackoverflow.com/questions/1/docs", want: false},
+		{link: "https://blog.example.com/doctor-who", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.link, func(t *testing.T) {
+			if got := mode.IsDocs(tt.link); got != tt.want {
+				t.Errorf("IsDocs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMode_Boost(t *testing.T) {
+	code, _ := mode.Parse("code")
+	concise, _ := mode.Parse("concise")
+
+	tests := []struct {
+		name string
+		mode mode.Mode
+		hit  retrieval.Hit
+		want float64
+	}{
+		{
+			name: "test prose",
+			mode: code,
+			hit:  retrieval.Hit{Link: "https://blog.example.com/a", Text: "Goroutines are cheap."},
+			want: 1,
+		},
+		{
+			name: "test code page",
+			mode: code,
+			hit:  retrieval.Hit{Link: "https://blog.example.com/a", Meta: map[string]any{vectorstorage.FieldContentType: "code"}},
+			want: 1.5,
+		},
+		{
+			name: "test code in docs",
+			mode: code,
+			hit:  retrieval.Hit{Link: "https://go.dev/doc/a", Text: "Run it:\n$ go run .\nfunc main() {\n}"},
+			want: 2.25,
+		},
+		{
+			name: "test no boost in mode",
+			mode: concise,
+			hit:  retrieval.Hit{Link: "https://go.dev/doc/a", Text: "$ go run .\nfunc main() {"},
+			want: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.mode.Boost(tt.hit); got != tt.want {
+				t.Errorf("Boost() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package prompt
+
+import (
+	"time"
+
+	"github.com/ary82/goseek/internal/retrieval"
+)
+
+// DefaultTemplate is the template used when no other one is selected
+const DefaultTemplate = "answer"
+
+// Data is what a prompt template can refer to
+type Data struct {
+	Query string
+	// Sources are the numbered context blocks, and Context is their text
+	Sources []retrieval.Source
+	Context string
+	Date    time.Time
+	// Locale is the user's locale, e.g. "en-US", or empty when unknown
+	Locale  string
+	History []Turn
+}
+
+// Turn is an earlier question of the conversation and its answer
+type Turn struct {
+	Query  string
+	Answer string
+}
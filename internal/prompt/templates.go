@@ -0,0 +1,266 @@
+package prompt
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/ary82/goseek/internal/retrieval"
+)
+
+//go:embed templates/*.tmpl
+var defaults embed.FS
+
+// Registry holds the prompt templates by name. The built-in templates can
+// be overridden and extended by the *.tmpl files of a directory, which is
+// reloaded when its files change, so prompts can be tuned without a
+// restart. A template is named by its path in the directory without the
+// extension, e.g. "answer" or "users/alice/answer".
+//
+// Every template other than answer is parsed on top of the built-in answer
+// template, so one that only redefines its blocks, such as "format", keeps
+// the shared instructions.
+type Registry struct {
+	dir string
+
+	mu        sync.RWMutex
+	stamp     string
+	templates map[string]*template.Template
+}
+
+// NewRegistry loads the built-in templates and those in dir, if it is not
+// empty. Every template is validated, and any invalid one fails the load.
+func NewRegistry(dir string) (*Registry, error) {
+	r := &Registry{
+		dir: dir,
+	}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Select returns the name of the template for a query mode and a user,
+// trying in order the user's template for the mode, the mode's template,
+// the user's default template and DefaultTemplate. Empty values are
+// skipped.
+func (r *Registry) Select(mode string, user string) string {
+	r.refresh()
+	if strings.Contains(user, "/") {
+		// Not a user name, and never a path into another user's templates
+		user = ""
+	}
+
+	var candidates []string
+	if mode != "" {
+		if user != "" {
+			candidates = append(candidates, "users/"+user+"/"+mode)
+		}
+		candidates = append(candidates, mode)
+	}
+	if user != "" {
+		candidates = append(candidates, "users/"+user+"/"+DefaultTemplate)
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, name := range candidates {
+		if _, ok := r.templates[name]; ok {
+			return name
+		}
+	}
+	return DefaultTemplate
+}
+
+// Render executes the named template with data
+func (r *Registry) Render(name string, data Data) (string, error) {
+	r.refresh()
+
+	r.mu.RLock()
+	t, ok := r.templates[name]
+	r.mu.RUnlock()
+	if !ok {
+		return "", fmt.Errorf("unknown prompt template %q", name)
+	}
+
+	var b strings.Builder
+	if err := t.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("error rendering prompt template %q: %w", name, err)
+	}
+	return b.String(), nil
+}
+
+// Names returns the names of all templates, sorted
+func (r *Registry) Names() []string {
+	r.refresh()
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.templates))
+	for name := range r.templates {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// refresh reloads the templates if the directory changed, keeping the
+// previous ones if the new ones are invalid
+func (r *Registry) refresh() {
+	if r.dir == "" {
+		return
+	}
+	if err := r.reload(); err != nil {
+		log.Printf("keeping previous prompt templates: %v", err)
+	}
+}
+
+// reload reads the templates again if the directory changed since the last
+// load
+func (r *Registry) reload() error {
+	var stamp string
+	if r.dir != "" {
+		var err error
+		stamp, err = dirStamp(r.dir)
+		if err != nil {
+			return fmt.Errorf("error reading prompt templates: %w", err)
+		}
+	}
+	r.mu.RLock()
+	unchanged := r.templates != nil && stamp == r.stamp
+	r.mu.RUnlock()
+	if unchanged {
+		return nil
+	}
+
+	templates := make(map[string]*template.Template)
+	builtin, _ := fs.Sub(defaults, "templates")
+	data, err := fs.ReadFile(builtin, DefaultTemplate+".tmpl")
+	if err != nil {
+		return err
+	}
+	base, err := parse(DefaultTemplate, string(data), nil)
+	if err != nil {
+		return err
+	}
+	if err := load(builtin, base, templates); err != nil {
+		return err
+	}
+	if r.dir != "" {
+		if err := load(os.DirFS(r.dir), base, templates); err != nil {
+			return fmt.Errorf("error in prompt templates %s: %w", r.dir, err)
+		}
+	}
+
+	r.mu.Lock()
+	r.templates = templates
+	r.stamp = stamp
+	r.mu.Unlock()
+	if r.dir != "" {
+		log.Printf("loaded %v prompt templates from %s", len(templates), r.dir)
+	}
+	return nil
+}
+
+// dirStamp identifies the state of the templates in dir, so that any added,
+// changed or removed file changes it
+func dirStamp(dir string) (string, error) {
+	var b strings.Builder
+	err := fs.WalkDir(os.DirFS(dir), ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || path.Ext(p) != ".tmpl" {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&b, "%s %d %d\n", p, info.Size(), info.ModTime().UnixNano())
+		return nil
+	})
+	return b.String(), err
+}
+
+// load parses and validates the *.tmpl files of fsys into templates,
+// replacing those with the same name. All but the default template are
+// parsed on top of base.
+func load(fsys fs.FS, base *template.Template, templates map[string]*template.Template) error {
+	return fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || path.Ext(p) != ".tmpl" {
+			return err
+		}
+		data, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return err
+		}
+		name := strings.TrimSuffix(p, ".tmpl")
+		from := base
+		if name == DefaultTemplate {
+			from = nil
+		}
+		t, err := parse(name, string(data), from)
+		if err != nil {
+			return err
+		}
+		if err := validate(t); err != nil {
+			return fmt.Errorf("template %q: %w", name, err)
+		}
+		templates[name] = t
+		return nil
+	})
+}
+
+// parse parses text as the template name, starting from the body and
+// blocks of base if it is not nil. A text that only defines blocks keeps the
+// body of base.
+func parse(name string, text string, base *template.Template) (*template.Template, error) {
+	t := template.New(name).Option("missingkey=error")
+	if base != nil {
+		for _, bt := range base.Templates() {
+			tname := bt.Name()
+			if tname == base.Name() {
+				tname = name
+			}
+			if _, err := t.AddParseTree(tname, bt.Tree); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return t.Parse(text)
+}
+
+// sample is the data templates are validated with
+var sample = Data{
+	Query: "sample query",
+	Sources: []retrieval.Source{
+		{N: 1, Link: "https://example.com", Title: "Example", Passages: []string{"sample passage"}},
+	},
+	Context: "[1] Example (https://example.com)\nsample passage\n\n",
+	Date:    time.Date(2025, time.January, 2, 0, 0, 0, 0, time.UTC),
+	Locale:  "en-US",
+	History: []Turn{{Query: "earlier query", Answer: "earlier answer"}},
+}
+
+// validate executes t with sample data, which catches references to
+// unknown fields, and checks the prompt contains the query and the context
+func validate(t *template.Template) error {
+	var b strings.Builder
+	if err := t.Execute(&b, sample); err != nil {
+		return err
+	}
+	out := b.String()
+	if !strings.Contains(out, sample.Query) {
+		return fmt.Errorf("prompt does not contain the query")
+	}
+	if !strings.Contains(out, "sample passage") {
+		return fmt.Errorf("prompt does not contain the context")
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package prompt_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ary82/goseek/internal/prompt"
+	"github.com/ary82/goseek/internal/retrieval"
+)
+
+func writeTemplate(t *testing.T, dir string, name string, text string) {
+	t.Helper()
+	p := filepath.Join(dir, name+".tmpl")
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(p, []byte(text), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRegistry_Render(t *testing.T) {
+	r, err := prompt.NewRegistry("")
+	if err != nil {
+		t.Fatalf("NewRegistry() error = %v", err)
+	}
+
+	got, err := r.Render(prompt.DefaultTemplate, prompt.Data{
+		Query:   "how do goroutines work",
+		Sources: []retrieval.Source{{N: 1, Link: "https://go.dev"}},
+		Context: "[1] https://go.dev\nGoroutines are cheap.\n\n",
+		Date:    time.Date(2025, time.March, 4, 0, 0, 0, 0, time.UTC),
+		Locale:  "de-DE",
+		History: []prompt.Turn{{Query: "what is go", Answer: "A language."}},
+	})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	for _, want := range []string{"how do goroutines work", "Goroutines are cheap.", "March 4, 2025", "de-DE", "User: what is go"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Render() does not contain %q:\n%s", want, got)
+		}
+	}
+
+	if _, err := r.Render("missing", prompt.Data{}); err == nil {
+		t.Errorf("Render() of an unknown template error = nil")
+	}
+}
+
+func TestNewRegistry(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		wantErr  bool
+	}{
+		{name: "test valid", template: "Q: {{ .Query }}\n{{ .Context }}"},
+		{name: "test parse error", template: "{{ .Query ", wantErr: true},
+		{name: "test unknown field", template: "{{ .Query }} {{ .Context }} {{ .Mood }}", wantErr: true},
+		{name: "test no query", template: "{{ .Context }}", wantErr: true},
+		{name: "test no context", template: "{{ .Query }}", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeTemplate(t, dir, "answer", tt.template)
+			_, err := prompt.NewRegistry(dir)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewRegistry() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRegistry_Select(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplate(t, dir, "report", "{{ .Query }} {{ .Context }}")
+	writeTemplate(t, dir, "users/ann/answer", "{{ .Query }} {{ .Context }}")
+	writeTemplate(t, dir, "users/ann/report", "{{ .Query }} {{ .Context }}")
+	r, err := prompt.NewRegistry(dir)
+	if err != nil {
+		t.Fatalf("NewRegistry() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		mode string
+		user string
+		want string
+	}{
+		{name: "test default", want: "answer"},
+		{name: "test mode", mode: "report", user: "bob", want: "report"},
+		{name: "test user", user: "ann", want: "users/ann/answer"},
+		{name: "test user mode", mode: "report", user: "ann", want: "users/ann/report"},
+		{name: "test unknown mode", mode: "poem", want: "answer"},
+		{name: "test path as user", mode: "report", user: "../ann", want: "report"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := r.Select(tt.mode, tt.user); got != tt.want {
+				t.Errorf("Select() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegistry_reload(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplate(t, dir, "answer", "v1 {{ .Query }} {{ .Context }}")
+	r, err := prompt.NewRegistry(dir)
+	if err != nil {
+		t.Fatalf("NewRegistry() error = %v", err)
+	}
+	data := prompt.Data{Query: "q", Context: "c"}
+
+	writeTemplate(t, dir, "answer", "version 2 {{ .Query }} {{ .Context }}")
+	if got, _ := r.Render("answer", data); got != "version 2 q c" {
+		t.Errorf("Render() after change = %q", got)
+	}
+
+	// An invalid change keeps the previous templates
+	writeTemplate(t, dir, "answer", "{{ .Query }}")
+	if got, _ := r.Render("answer", data); got != "version 2 q c" {
+		t.Errorf("Render() after invalid change = %q", got)
+	}
+
+	// Removing the override brings back the built-in template
+	if err := os.Remove(filepath.Join(dir, "answer.tmpl")); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := r.Render("answer", data); !strings.HasPrefix(got, "You are an expert") {
+		t.Errorf("Render() after removal = %q", got)
+	}
+}
+
+func TestRegistry_blocks(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplate(t, dir, "brief", `{{ define "format" }}Answer in one sentence.{{ end }}`)
+	r, err := prompt.NewRegistry(dir)
+	if err != nil {
+		t.Fatalf("NewRegistry() error = %v", err)
+	}
+	data := prompt.Data{Query: "q", Context: "c"}
+
+	tests := []struct {
+		name   string
+		want   string
+		unwant string
+	}{
+		{name: "answer", want: "Write a coherent answer."},
+		{name: "bullets", want: `one "- " bullet per key point`, unwant: "Write a coherent answer."},
+		{name: "brief", want: "Answer in one sentence.", unwant: "Write a coherent answer."},
+	}
+	for _, tt := range tests {
+		t.Run("test "+tt.name, func(t *testing.T) {
+			got, err := r.Render(tt.name, data)
+			if err != nil {
+				t.Fatalf("Render() error = %v", err)
+			}
+			// The shared instructions of answer.tmpl are kept
+			for _, want := range []string{"You are an expert", "Cite the sources", tt.want} {
+				if !strings.Contains(got, want) {
+					t.Errorf("Render() does not contain %q:\n%s", want, got)
+				}
+			}
+			if tt.unwant != "" && strings.Contains(got, tt.unwant) {
+				t.Errorf("Render() contains %q:\n%s", tt.unwant, got)
+			}
+		})
+	}
+}
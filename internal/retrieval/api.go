@@ -0,0 +1,45 @@
+package retrieval
+
+import "fmt"
+
+// Hit is a retrieved chunk with its relevance score
+type Hit struct {
+	ID string
+	// ParentID is the chunk handed to the LLM in place of this one, if any
+	ParentID string
+	Link     string
+	// Sources lists every link the chunk's text was found on, see
+	// chunk.Chunk
+	Sources []string
+	Title   string
+	Text    string
+	// Weight scales Score, see ApplyWeights; 0 is treated as 1
+	Weight float64
+	Score  float64
+	// Meta is the filterable metadata of the chunk's page
+	Meta map[string]any
+}
+
+// Strategy selects how chunks are retrieved
+type Strategy string
+
+const (
+	// StrategyDense uses vector search only
+	StrategyDense Strategy = "dense"
+	// StrategySparse uses the BM25 keyword index only
+	StrategySparse Strategy = "sparse"
+	// StrategyHybrid fuses vector and keyword results by reciprocal rank
+	StrategyHybrid Strategy = "hybrid"
+)
+
+// ParseStrategy parses a strategy name, defaulting to StrategyHybrid when s
+// is empty
+func ParseStrategy(s string) (Strategy, error) {
+	switch st := Strategy(s); st {
+	case "":
+		return StrategyHybrid, nil
+	case StrategyDense, StrategySparse, StrategyHybrid:
+		return st, nil
+	}
+	return "", fmt.Errorf("unknown retrieval strategy %q", s)
+}
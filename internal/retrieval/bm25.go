@@ -0,0 +1,140 @@
+package retrieval
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// BM25Index is an in-memory keyword index. Its tokens keep identifiers such
+// as error codes, function names and version strings whole, so exact
+// matches rank first, and also index their parts.
+type BM25Index struct {
+	mu       sync.RWMutex
+	byID     map[string]int
+	hits     []Hit
+	termFreq []map[string]int
+	lengths  []int
+	docFreq  map[string]int
+	totalLen int
+}
+
+func NewBM25Index() *BM25Index {
+	return &BM25Index{
+		byID:    make(map[string]int),
+		docFreq: make(map[string]int),
+	}
+}
+
+// Add indexes the text of h. Adding an ID again only updates the hit, as
+// IDs are derived from the content.
+func (idx *BM25Index) Add(h Hit) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	if i, ok := idx.byID[h.ID]; ok {
+		idx.hits[i] = h
+		return
+	}
+
+	terms := keywordTerms(h.Text)
+	tf := make(map[string]int, len(terms))
+	for _, t := range terms {
+		tf[t]++
+	}
+
+	for t := range tf {
+		idx.docFreq[t]++
+	}
+	idx.byID[h.ID] = len(idx.hits)
+	idx.hits = append(idx.hits, h)
+	idx.termFreq = append(idx.termFreq, tf)
+	idx.lengths = append(idx.lengths, len(terms))
+	idx.totalLen += len(terms)
+}
+
+// Len returns the number of indexed hits
+func (idx *BM25Index) Len() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return len(idx.hits)
+}
+
+// Search returns the k best matching hits for query, best first
+func (idx *BM25Index) Search(query string, k int) []Hit {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	if len(idx.hits) == 0 {
+		return nil
+	}
+
+	n := float64(len(idx.hits))
+	avgLen := float64(idx.totalLen) / n
+	var results []Hit
+	for i, tf := range idx.termFreq {
+		score := 0.0
+		for _, t := range uniqueTerms(keywordTerms(query)) {
+			f := float64(tf[t])
+			if f == 0 {
+				continue
+			}
+			df := float64(idx.docFreq[t])
+			idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+			norm := f + bm25K1*(1-bm25B+bm25B*float64(idx.lengths[i])/avgLen)
+			score += idf * f * (bm25K1 + 1) / norm
+		}
+		if score > 0 {
+			h := idx.hits[i]
+			h.Score = score
+			results = append(results, h)
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	return results[:min(k, len(results))]
+}
+
+// keywordTerms lowercases text into tokens, keeping identifiers like
+// "ERR_CONNECTION_RESET", "os.ReadFile" and "v1.24.3" whole and adding
+// their dot, dash and underscore separated parts
+func keywordTerms(text string) []string {
+	tokens := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r) && !strings.ContainsRune("._-", r)
+	})
+
+	var terms []string
+	for _, tok := range tokens {
+		tok = strings.Trim(tok, "._-")
+		if tok == "" {
+			continue
+		}
+		terms = append(terms, tok)
+		parts := strings.FieldsFunc(tok, func(r rune) bool {
+			return strings.ContainsRune("._-", r)
+		})
+		if len(parts) > 1 {
+			terms = append(terms, parts...)
+		}
+	}
+	return terms
+}
+
+func uniqueTerms(terms []string) []string {
+	seen := make(map[string]bool, len(terms))
+	unique := terms[:0:0]
+	for _, t := range terms {
+		if !seen[t] {
+			seen[t] = true
+			unique = append(unique, t)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,42 @@
+package retrieval_test
+
+import (
+	"testing"
+
+	"github.com/ary82/goseek/internal/retrieval"
+)
+
+func TestBM25Index_Search(t *testing.T) {
+	idx := retrieval.NewBM25Index()
+	for _, h := range []retrieval.Hit{
+		{ID: "net", Text: "The browser shows ERR_CONNECTION_RESET when the server drops the connection."},
+		{ID: "read", Text: "Use os.ReadFile to read a whole file into memory."},
+		{ID: "release", Text: "Go v1.24.3 fixes a security issue in net/http."},
+		{ID: "misc", Text: "Files and connections should be closed when they are no longer needed."},
+	} {
+		idx.Add(h)
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{name: "test error code", query: "what causes ERR_CONNECTION_RESET", want: "net"},
+		{name: "test function name", query: "os.ReadFile example", want: "read"},
+		{name: "test version string", query: "changes in v1.24.3", want: "release"},
+		{name: "test identifier part", query: "ReadFile", want: "read"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := idx.Search(tt.query, 2)
+			if len(got) == 0 || got[0].ID != tt.want {
+				t.Errorf("Search() = %+v, want %v first", got, tt.want)
+			}
+		})
+	}
+
+	if got := idx.Search("kubernetes", 2); len(got) != 0 {
+		t.Errorf("Search() with no matching terms = %+v, want none", got)
+	}
+}
@@ -0,0 +1,158 @@
+package retrieval
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/ary82/goseek/internal/chunk"
+)
+
+// minTruncatedTokens is the smallest remainder of the budget worth filling
+// with a truncated passage
+const minTruncatedTokens = 48
+
+// Source is a reference in the LLM context: every passage taken from one
+// link, under one number
+type Source struct {
+	N        int
+	Link     string
+	Title    string
+	Passages []string
+	// Also lists the other links the passages were found on
+	Also []string
+}
+
+// ContextBuilder packs hits into an LLM context that fits a token budget
+type ContextBuilder struct {
+	Budget int
+	// Tokenizer measures the context; nil estimates 4 bytes per token
+	Tokenizer chunk.Tokenizer
+}
+
+func NewContextBuilder(budget int, tok chunk.Tokenizer) *ContextBuilder {
+	return &ContextBuilder{
+		Budget:    budget,
+		Tokenizer: tok,
+	}
+}
+
+// Build adds hits, best first, until the budget is spent. Sources are
+// numbered from 1 in order of their best hit, and every hit joins its
+// source. The hit that overflows the budget is truncated at a sentence or
+// word boundary when enough budget is left, and later hits are dropped.
+func (cb *ContextBuilder) Build(hits []Hit) ([]Source, string) {
+	var (
+		sources []Source
+		byLink  = make(map[string]int)
+		seen    = make(map[string]bool)
+		used    int
+	)
+
+	for _, h := range hits {
+		text := strings.TrimSpace(h.Text)
+		if text == "" || seen[text] {
+			continue
+		}
+
+		i, known := byLink[h.Link]
+		cost := cb.tokens(text + "\n\n")
+		if !known {
+			cost += cb.tokens(sourceHeader(len(sources)+1, h.Link, h.Title))
+		}
+
+		if used+cost > cb.Budget {
+			room := cb.Budget - used - (cost - cb.tokens(text+"\n\n"))
+			if room < minTruncatedTokens {
+				break
+			}
+			text = cb.truncate(text, room)
+			if text == "" {
+				break
+			}
+			cost = cb.Budget - used
+		}
+
+		if !known {
+			i = len(sources)
+			byLink[h.Link] = i
+			sources = append(sources, Source{N: i + 1, Link: h.Link, Title: h.Title})
+		}
+		sources[i].Passages = append(sources[i].Passages, text)
+		for _, l := range h.Sources {
+			if l != h.Link && !slices.Contains(sources[i].Also, l) {
+				sources[i].Also = append(sources[i].Also, l)
+			}
+		}
+		seen[strings.TrimSpace(h.Text)] = true
+		used += cost
+		if used >= cb.Budget {
+			break
+		}
+	}
+
+	out := FormatSources(sources)
+	log.Printf("context built from %v sources with %v of %v tokens", len(sources), cb.tokens(out), cb.Budget)
+	return sources, out
+}
+
+// FormatSources renders sources as the numbered references of the context
+func FormatSources(sources []Source) string {
+	var b strings.Builder
+	for _, s := range sources {
+		b.WriteString(sourceHeader(s.N, s.Link, s.Title))
+		for _, p := range s.Passages {
+			b.WriteString(p)
+			b.WriteString("\n\n")
+		}
+	}
+	return b.String()
+}
+
+func sourceHeader(n int, link string, title string) string {
+	if title == "" {
+		return fmt.Sprintf("[%d] %s\n", n, link)
+	}
+	return fmt.Sprintf("[%d] %s (%s)\n", n, title, link)
+}
+
+// truncate cuts text to at most budget tokens, ending after a sentence when
+// one ends in its second half, else after a word, and marks the cut
+func (cb *ContextBuilder) truncate(text string, budget int) string {
+	const ellipsis = " …"
+	budget -= cb.tokens(ellipsis + "\n\n")
+
+	// Longest prefix that fits, by binary search over rune offsets
+	lo, hi := 0, utf8.RuneCountInString(text)
+	runes := []rune(text)
+	for lo < hi {
+		mid := (lo + hi + 1) / 2
+		if cb.tokens(string(runes[:mid])) <= budget {
+			lo = mid
+		} else {
+			hi = mid - 1
+		}
+	}
+	prefix := string(runes[:lo])
+	if prefix == "" {
+		return ""
+	}
+
+	if end := strings.LastIndexAny(prefix, ".!?"); end >= len(prefix)/2 {
+		return prefix[:end+1] + ellipsis
+	}
+	if end := strings.LastIndexFunc(prefix, unicode.IsSpace); end > 0 {
+		prefix = prefix[:end]
+	}
+	return strings.TrimRightFunc(prefix, unicode.IsSpace) + ellipsis
+}
+
+func (cb *ContextBuilder) tokens(text string) int {
+	if cb.Tokenizer == nil {
+		return chunk.NewApproxTokenizer().Count(text)
+	}
+	return cb.Tokenizer.Count(text)
+}
@@ -0,0 +1,77 @@
+package retrieval_test
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ary82/goseek/internal/chunk"
+	"github.com/ary82/goseek/internal/retrieval"
+)
+
+func TestContextBuilder_Build(t *testing.T) {
+	long := strings.Repeat("The quick brown fox jumps over the lazy dog. ", 40)
+	hits := []retrieval.Hit{
+		{Link: "https://a.example.com", Sources: []string{"https://a.example.com", "https://mirror.example.com"}, Title: "A", Text: "Alpha passage one."},
+		{Link: "https://b.example.com", Title: "B", Text: "Beta passage."},
+		{Link: "https://a.example.com", Title: "A", Text: "Alpha passage two."},
+		{Link: "https://a.example.com", Title: "A", Text: "Alpha passage one."},
+		{Link: "https://c.example.com", Text: long},
+		{Link: "https://d.example.com", Title: "D", Text: "Never reached."},
+	}
+
+	tests := []struct {
+		name        string
+		budget      int
+		wantSources []string
+		wantCounts  []int
+		wantCut     bool
+	}{
+		{
+			name:        "test everything fits",
+			budget:      10000,
+			wantSources: []string{"https://a.example.com", "https://b.example.com", "https://c.example.com", "https://d.example.com"},
+			wantCounts:  []int{2, 1, 1, 1},
+		},
+		{
+			name:        "test truncated",
+			budget:      150,
+			wantSources: []string{"https://a.example.com", "https://b.example.com", "https://c.example.com"},
+			wantCounts:  []int{2, 1, 1},
+			wantCut:     true,
+		},
+		{
+			name:        "test too little room to truncate",
+			budget:      40,
+			wantSources: []string{"https://a.example.com", "https://b.example.com"},
+			wantCounts:  []int{2, 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tok := chunk.NewApproxTokenizer()
+			sources, context := retrieval.NewContextBuilder(tt.budget, tok).Build(hits)
+
+			if got := tok.Count(context); got > tt.budget {
+				t.Errorf("Build() context has %v tokens, over budget %v", got, tt.budget)
+			}
+			if len(sources) != len(tt.wantSources) {
+				t.Fatalf("Build() returned %v sources, want %v", len(sources), len(tt.wantSources))
+			}
+			for i, s := range sources {
+				if s.N != i+1 || s.Link != tt.wantSources[i] || len(s.Passages) != tt.wantCounts[i] {
+					t.Errorf("source %d = %+v, want [%d] %v with %v passages", i, s, i+1, tt.wantSources[i], tt.wantCounts[i])
+				}
+			}
+			if !slices.Equal(sources[0].Also, []string{"https://mirror.example.com"}) {
+				t.Errorf("Build() source 1 also found on %v, want the mirror", sources[0].Also)
+			}
+			if !strings.HasPrefix(context, "[1] A (https://a.example.com)\nAlpha passage one.\n\nAlpha passage two.") {
+				t.Errorf("Build() context does not group source 1: %q", context[:min(len(context), 80)])
+			}
+			if cut := strings.Contains(context, "dog. …"); cut != tt.wantCut {
+				t.Errorf("Build() truncated = %v, want %v", cut, tt.wantCut)
+			}
+		})
+	}
+}
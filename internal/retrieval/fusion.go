@@ -0,0 +1,47 @@
+package retrieval
+
+import "sort"
+
+// rrfK dampens the influence of the top ranks in reciprocal rank fusion
+const rrfK = 60
+
+// FuseRRF merges ranked lists by reciprocal rank fusion: a hit scores
+// 1/(rrfK+rank) in every list it appears in. Hits are matched by ID.
+func FuseRRF(lists ...[]Hit) []Hit {
+	scores := make(map[string]float64)
+	hits := make(map[string]Hit)
+	var order []string
+	for _, list := range lists {
+		for rank, h := range list {
+			if _, ok := hits[h.ID]; !ok {
+				hits[h.ID] = h
+				order = append(order, h.ID)
+			}
+			scores[h.ID] += 1 / float64(rrfK+rank+1)
+		}
+	}
+
+	fused := make([]Hit, 0, len(order))
+	for _, id := range order {
+		h := hits[id]
+		h.Score = scores[id]
+		fused = append(fused, h)
+	}
+	sort.SliceStable(fused, func(i, j int) bool {
+		return fused[i].Score > fused[j].Score
+	})
+	return fused
+}
+
+// ApplyWeights scales every score by the hit's Weight and re-sorts, best first
+func ApplyWeights(hits []Hit) []Hit {
+	for i := range hits {
+		if hits[i].Weight > 0 {
+			hits[i].Score *= hits[i].Weight
+		}
+	}
+	sort.SliceStable(hits, func(i, j int) bool {
+		return hits[i].Score > hits[j].Score
+	})
+	return hits
+}
@@ -0,0 +1,47 @@
+package retrieval_test
+
+import (
+	"testing"
+
+	"github.com/ary82/goseek/internal/retrieval"
+)
+
+func ids(hits []retrieval.Hit) []string {
+	var out []string
+	for _, h := range hits {
+		out = append(out, h.ID)
+	}
+	return out
+}
+
+func TestFuseRRF(t *testing.T) {
+	dense := []retrieval.Hit{{ID: "a"}, {ID: "b"}, {ID: "c"}}
+	sparse := []retrieval.Hit{{ID: "c"}, {ID: "d"}, {ID: "b"}}
+
+	got := ids(retrieval.FuseRRF(dense, sparse))
+	// b and c appear in both lists, c ranks higher on average
+	want := []string{"c", "b", "a", "d"}
+	if len(got) != len(want) {
+		t.Fatalf("FuseRRF() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("FuseRRF() = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestApplyWeights(t *testing.T) {
+	hits := []retrieval.Hit{
+		{ID: "comment", Score: 1, Weight: 0.6},
+		{ID: "accepted", Score: 0.8, Weight: 1.5},
+		{ID: "plain", Score: 0.9},
+	}
+	got := ids(retrieval.ApplyWeights(hits))
+	want := []string{"accepted", "plain", "comment"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("ApplyWeights() = %v, want %v", got, want)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package retrieval
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"net/url"
+	"strings"
+
+	"github.com/ary82/goseek/internal/embed"
+)
+
+// MMR selects hits by maximal marginal relevance, trading the relevance of
+// a hit against its similarity to the hits already selected, so the
+// selection spans several sources
+type MMR struct {
+	Embedder embed.Embedder
+	// Lambda is the weight of relevance against diversity, from 0 (only
+	// diversity) to 1 (only relevance)
+	Lambda float64
+	// PerSource caps the hits selected from one site; 0 means no cap. The
+	// cap is relaxed when too few other sites are left to select k hits.
+	PerSource int
+}
+
+func NewMMR(e embed.Embedder, lambda float64, perSource int) *MMR {
+	return &MMR{
+		Embedder:  e,
+		Lambda:    lambda,
+		PerSource: perSource,
+	}
+}
+
+// Select returns up to k of hits, which are ranked best first, in selection
+// order
+func (m *MMR) Select(ctx context.Context, hits []Hit, k int) ([]Hit, error) {
+	if len(hits) <= k && m.PerSource == 0 {
+		return hits, nil
+	}
+
+	relevance := normalizedScores(hits)
+	var vectors [][]float32
+	if m.Lambda < 1 {
+		texts := make([]string, len(hits))
+		for i, h := range hits {
+			texts[i] = h.Text
+		}
+		var err error
+		vectors, err = m.Embedder.Embed(ctx, texts)
+		if err != nil {
+			return nil, fmt.Errorf("error embedding hits: %w", err)
+		}
+		if len(vectors) != len(hits) {
+			return nil, fmt.Errorf("embedder returned %d vectors for %d hits", len(vectors), len(hits))
+		}
+	}
+
+	var (
+		selected  []int
+		taken     = make([]bool, len(hits))
+		perSource = make(map[string]int)
+	)
+	pick := func(capped bool) bool {
+		best, bestScore := -1, math.Inf(-1)
+		for i := range hits {
+			if taken[i] || (capped && m.PerSource > 0 && perSource[siteOf(hits[i].Link)] >= m.PerSource) {
+				continue
+			}
+			redundancy := 0.0
+			for _, j := range selected {
+				if vectors != nil {
+					redundancy = max(redundancy, embed.Cosine(vectors[i], vectors[j]))
+				}
+			}
+			if score := m.Lambda*relevance[i] - (1-m.Lambda)*redundancy; score > bestScore {
+				best, bestScore = i, score
+			}
+		}
+		if best == -1 {
+			return false
+		}
+		taken[best] = true
+		selected = append(selected, best)
+		perSource[siteOf(hits[best].Link)]++
+		return true
+	}
+
+	for len(selected) < k && pick(true) {
+	}
+	for len(selected) < k && pick(false) {
+	}
+
+	out := make([]Hit, len(selected))
+	for i, j := range selected {
+		out[i] = hits[j]
+	}
+	log.Printf("mmr selected %v of %v hits from %v sources", len(out), len(hits), len(perSource))
+	return out, nil
+}
+
+// normalizedScores maps hit scores to [0, 1]
+func normalizedScores(hits []Hit) []float64 {
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, h := range hits {
+		lo, hi = min(lo, h.Score), max(hi, h.Score)
+	}
+	out := make([]float64, len(hits))
+	for i, h := range hits {
+		if hi > lo {
+			out[i] = (h.Score - lo) / (hi - lo)
+		} else {
+			out[i] = 1
+		}
+	}
+	return out
+}
+
+// siteOf is the host of link without a leading "www."
+func siteOf(link string) string {
+	u, err := url.Parse(link)
+	if err != nil {
+		return link
+	}
+	return strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+}
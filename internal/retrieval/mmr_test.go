@@ -0,0 +1,50 @@
+package retrieval_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/ary82/goseek/internal/embed"
+	"github.com/ary82/goseek/internal/retrieval"
+)
+
+func TestMMR_Select(t *testing.T) {
+	install := "Install the package with go get and import it in your module."
+	hits := []retrieval.Hit{
+		{ID: "a1", Link: "https://a.example.com/1", Text: install, Score: 1},
+		{ID: "a2", Link: "https://a.example.com/2", Text: install + " Then run go mod tidy.", Score: 0.95},
+		{ID: "a3", Link: "https://www.a.example.com/3", Text: "Configure the logger before starting the server.", Score: 0.9},
+		{ID: "b1", Link: "https://b.example.org/x", Text: "Benchmarks show the parser is twice as fast as before.", Score: 0.6},
+		{ID: "c1", Link: "https://c.example.net/y", Text: "The project is licensed under the MIT license.", Score: 0.3},
+	}
+
+	tests := []struct {
+		name      string
+		lambda    float64
+		perSource int
+		k         int
+		want      []string
+	}{
+		{name: "test relevance only", lambda: 1, k: 3, want: []string{"a1", "a2", "a3"}},
+		{name: "test diversity", lambda: 0.5, k: 3, want: []string{"a1", "a3", "b1"}},
+		{name: "test per source cap", lambda: 1, perSource: 1, k: 3, want: []string{"a1", "b1", "c1"}},
+		{name: "test relaxed cap", lambda: 1, perSource: 1, k: 4, want: []string{"a1", "b1", "c1", "a2"}},
+	}
+	e, err := embed.NewHashEmbedder(256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := retrieval.NewMMR(e, tt.lambda, tt.perSource)
+			got, err := m.Select(context.Background(), slices.Clone(hits), tt.k)
+			if err != nil {
+				t.Fatalf("Select() failed: %v", err)
+			}
+			if !slices.Equal(ids(got), tt.want) {
+				t.Errorf("Select() = %v, want %v", ids(got), tt.want)
+			}
+		})
+	}
+}
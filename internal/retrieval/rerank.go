@@ -0,0 +1,194 @@
+package retrieval
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ary82/goseek/internal/constants"
+	"github.com/ary82/goseek/internal/llm"
+)
+
+// Reranker reorders retrieved hits by relevance to the query, best first.
+// It returns the hits with their Score replaced by its own.
+type Reranker interface {
+	Rerank(ctx context.Context, query string, hits []Hit) ([]Hit, error)
+}
+
+// rerankPassageLen bounds how much of each hit is shown to the LLM
+const rerankPassageLen = 600
+
+type llmReranker struct {
+	llm llm.LLM
+}
+
+// NewLLMReranker asks the LLM to order all hits at once (listwise). Hits it
+// leaves out are kept after the ranked ones in their original order.
+func NewLLMReranker(l llm.LLM) Reranker {
+	return &llmReranker{
+		llm: l,
+	}
+}
+
+func (lr *llmReranker) Rerank(ctx context.Context, query string, hits []Hit) ([]Hit, error) {
+	if len(hits) < 2 {
+		return hits, nil
+	}
+
+	var passages strings.Builder
+	for i, h := range hits {
+		text := strings.Join(strings.Fields(h.Text), " ")
+		if len(text) > rerankPassageLen {
+			text = strings.ToValidUTF8(text[:rerankPassageLen], "") + "…"
+		}
+		fmt.Fprintf(&passages, "[%d] %s\n\n", i+1, text)
+	}
+
+	response, err := lr.llm.GenerateContent(ctx, fmt.Sprintf(constants.RERANK_PROMPT, query, passages.String()))
+	if err != nil {
+		return nil, fmt.Errorf("error reranking with LLM: %w", err)
+	}
+
+	order := parseRanking(*response, len(hits))
+	log.Printf("llm rerank succeeded ranking %v of %v hits", len(order), len(hits))
+	return reorder(hits, order), nil
+}
+
+var rankNumber = regexp.MustCompile(`\d+`)
+
+// parseRanking reads the 0-based hit indexes from an LLM ranking, dropping
+// out of range and repeated numbers
+func parseRanking(response string, n int) []int {
+	var order []int
+	seen := make(map[int]bool)
+	for _, m := range rankNumber.FindAllString(response, -1) {
+		i, err := strconv.Atoi(m)
+		if err != nil || i < 1 || i > n || seen[i-1] {
+			continue
+		}
+		seen[i-1] = true
+		order = append(order, i-1)
+	}
+	return order
+}
+
+// reorder puts the hits at order first, then the rest, and scores them by
+// their new rank
+func reorder(hits []Hit, order []int) []Hit {
+	ranked := make([]Hit, 0, len(hits))
+	placed := make(map[int]bool, len(order))
+	for _, i := range order {
+		ranked = append(ranked, hits[i])
+		placed[i] = true
+	}
+	for i, h := range hits {
+		if !placed[i] {
+			ranked = append(ranked, h)
+		}
+	}
+	for i := range ranked {
+		ranked[i].Score = 1 / float64(i+1)
+	}
+	return ranked
+}
+
+type lexicalReranker struct{}
+
+// NewLexicalReranker scores hits offline by how many query terms they
+// contain, whether they contain the query as a phrase, how close together
+// the terms are, and their original rank
+func NewLexicalReranker() Reranker {
+	return lexicalReranker{}
+}
+
+func (lexicalReranker) Rerank(ctx context.Context, query string, hits []Hit) ([]Hit, error) {
+	terms := uniqueTerms(keywordTerms(query))
+	phrase := strings.Join(strings.Fields(strings.ToLower(query)), " ")
+
+	ranked := make([]Hit, len(hits))
+	for rank, h := range hits {
+		text := strings.ToLower(strings.Join(strings.Fields(h.Text), " "))
+		words := keywordTerms(h.Text)
+
+		score := 0.15 / float64(rank+1)
+		if len(terms) > 0 {
+			score += 0.5 * termCoverage(terms, words)
+			score += 0.15 * proximity(terms, words)
+		}
+		if phrase != "" && strings.Contains(text, phrase) {
+			score += 0.2
+		}
+
+		h.Score = score
+		ranked[rank] = h
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].Score > ranked[j].Score
+	})
+	return ranked, nil
+}
+
+// termCoverage is the share of terms that occur in words
+func termCoverage(terms []string, words []string) float64 {
+	present := make(map[string]bool, len(words))
+	for _, w := range words {
+		present[w] = true
+	}
+	found := 0
+	for _, t := range terms {
+		if present[t] {
+			found++
+		}
+	}
+	return float64(found) / float64(len(terms))
+}
+
+// proximity is 1 when the matched terms occur next to each other and falls
+// towards 0 as the shortest span containing them all grows
+func proximity(terms []string, words []string) float64 {
+	wanted := make(map[string]bool, len(terms))
+	for _, t := range terms {
+		wanted[t] = true
+	}
+
+	// Shortest window of words containing every matched term
+	inWindow := make(map[string]int)
+	need := 0
+	for _, w := range words {
+		if wanted[w] && inWindow[w] == 0 {
+			need++
+			inWindow[w] = 1
+		}
+	}
+	if need < 2 {
+		return 0
+	}
+	clear(inWindow)
+
+	best, have, lo := len(words)+1, 0, 0
+	for hi, w := range words {
+		if !wanted[w] {
+			continue
+		}
+		if inWindow[w]++; inWindow[w] == 1 {
+			have++
+		}
+		for have == need {
+			if size := hi - lo + 1; size < best {
+				best = size
+			}
+			if l := words[lo]; wanted[l] {
+				if inWindow[l]--; inWindow[l] == 0 {
+					have--
+				}
+			}
+			lo++
+		}
+	}
+	return float64(need) / float64(best)
+}
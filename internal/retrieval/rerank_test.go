@@ -0,0 +1,76 @@
+package retrieval_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ary82/goseek/internal/retrieval"
+)
+
+type llmMock struct {
+	response string
+	err      error
+	prompt   string
+}
+
+func (m *llmMock) GenerateContent(ctx context.Context, prompt string) (*string, error) {
+	m.prompt = prompt
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &m.response, nil
+}
+
+func TestLLMReranker_Rerank(t *testing.T) {
+	hits := []retrieval.Hit{{ID: "a", Text: "first"}, {ID: "b", Text: "second"}, {ID: "c", Text: "third"}}
+
+	tests := []struct {
+		name     string
+		response string
+		err      error
+		want     []string
+		wantErr  bool
+	}{
+		{name: "test ranking", response: "3, 1, 2", want: []string{"c", "a", "b"}},
+		{name: "test partial ranking", response: "Ranking: [3], [9], [3], [1]", want: []string{"c", "a", "b"}},
+		{name: "test no ranking", response: "none are relevant", want: []string{"a", "b", "c"}},
+		{name: "test llm error", err: errors.New("quota exceeded"), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &llmMock{response: tt.response, err: tt.err}
+			got, gotErr := retrieval.NewLLMReranker(m).Rerank(context.Background(), "what is third", hits)
+			if (gotErr != nil) != tt.wantErr {
+				t.Fatalf("Rerank() error = %v, wantErr %v", gotErr, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !slices.Equal(ids(got), tt.want) {
+				t.Errorf("Rerank() = %v, want %v", ids(got), tt.want)
+			}
+			if !strings.Contains(m.prompt, "[3] third") {
+				t.Errorf("prompt %q does not list the passages", m.prompt)
+			}
+		})
+	}
+}
+
+func TestLexicalReranker_Rerank(t *testing.T) {
+	hits := []retrieval.Hit{
+		{ID: "loose", Text: "Connection pools help. Many things time out. Sometimes a reset happens too."},
+		{ID: "unrelated", Text: "Cats sleep most of the day."},
+		{ID: "exact", Text: "Fixing a connection reset timeout in the HTTP client."},
+	}
+	got, err := retrieval.NewLexicalReranker().Rerank(context.Background(), "connection reset timeout", hits)
+	if err != nil {
+		t.Fatalf("Rerank() failed: %v", err)
+	}
+	want := []string{"exact", "loose", "unrelated"}
+	if !slices.Equal(ids(got), want) {
+		t.Errorf("Rerank() = %v, want %v", ids(got), want)
+	}
+}
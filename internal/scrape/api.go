@@ -1,15 +1,76 @@
 package scrape
 
-import "context"
+import (
+	"context"
+	"net/url"
+	"time"
 
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Scraper fetches the text content of web pages. Scrape returns an entry for
+// every URL; failed URLs carry a *ScrapeError in ScrapedContent.Error.
+//
+// ScrapeStream emits each result as soon as it is ready and closes the
+// channel once every URL is done or ctx is cancelled.
+//
+// Crawl behaves like ScrapeStream and, when crawling is enabled, also
+// follows same-site links from scraped pages, most relevant to query first.
 type Scraper interface {
 	Scrape(ctx context.Context, urls []string) (map[string]ScrapedContent, error)
+	ScrapeStream(ctx context.Context, urls []string) <-chan ScrapedContent
+	Crawl(ctx context.Context, query string, urls []string) <-chan ScrapedContent
+}
+
+// Renderer returns the HTML of a page after its client-side scripts have run
+type Renderer interface {
+	Render(ctx context.Context, url string) (string, error)
+}
+
+// Extractor pulls the separate posts out of a forum or Q&A page, such as a
+// question and its answers. Match reports whether it understands the page.
+type Extractor interface {
+	Match(u *url.URL, doc *goquery.Document) bool
+	Extract(doc *goquery.Document) []Post
+}
+
+type PostKind string
+
+const (
+	PostQuestion PostKind = "question"
+	PostAnswer   PostKind = "answer"
+	PostComment  PostKind = "comment"
+)
+
+// Post is a single contribution to a thread. Score is the net votes or
+// likes, and Accepted marks the answer chosen by the asker.
+type Post struct {
+	Kind     PostKind
+	Author   string
+	Score    int
+	Accepted bool
+	Content  string
 }
 
 type ScrapedContent struct {
 	Content string
-	URL     string
-	Error   error
+	// Markup is the body HTML without scripts and page chrome, for
+	// structure-aware chunking. It is empty when the text came from
+	// embedded page data.
+	Markup string
+	Title  string
+	URL    string
+	// Lang is the declared language of the page, e.g. "en-US"
+	Lang string
+	// Published is the publication date of the page, or the zero time if
+	// it declares none
+	Published time.Time
+	Error     error
+	// Depth is the number of links followed from a given URL to reach this page
+	Depth int
+	// Posts are the posts of a forum or Q&A page, in page order, when an
+	// Extractor matched it
+	Posts []Post
 }
 
 type Content struct{}
@@ -0,0 +1,261 @@
+package scrape
+
+import (
+	"context"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// CrawlConfig bounds link following in Crawl. A zero MaxDepth disables it.
+type CrawlConfig struct {
+	// MaxDepth is how many links away from the given URLs to go
+	MaxDepth int
+	// MaxPages is the number of followed pages allowed per Crawl call,
+	// DefaultCrawlPages when not positive
+	MaxPages int
+	// HostDelay is the minimum time between requests to the same host
+	HostDelay time.Duration
+}
+
+// DefaultCrawlPages is the page budget of a crawl that doesn't set one
+const DefaultCrawlPages = 20
+
+// pageLink is a link found on a page with its anchor text
+type pageLink struct {
+	url    string
+	anchor string
+}
+
+func (w *webScraper) Crawl(ctx context.Context, query string, urls []string) <-chan ScrapedContent {
+	out := make(chan ScrapedContent, len(urls))
+
+	go func() {
+		defer close(out)
+
+		terms := queryTerms(query)
+		visited := make(map[string]bool)
+		for _, u := range urls {
+			visited[normalizeLink(u)] = true
+		}
+
+		frontier := urls
+		budget := w.crawl.MaxPages
+		for depth := 0; len(frontier) > 0; depth++ {
+			var found []pageLink
+			for res := range w.scrapePages(ctx, frontier, depth) {
+				out <- res.content
+				for _, l := range res.links {
+					// Don't spend the page budget on links robots.txt disallows
+					if w.linkAllowed(ctx, l) {
+						found = append(found, l)
+					}
+				}
+			}
+			if depth >= w.crawl.MaxDepth || budget <= 0 || ctx.Err() != nil {
+				return
+			}
+
+			frontier = nextFrontier(found, terms, visited, budget)
+			budget -= len(frontier)
+		}
+	}()
+
+	return out
+}
+
+// linkAllowed reports whether robots.txt lets the crawler follow l
+func (w *webScraper) linkAllowed(ctx context.Context, l pageLink) bool {
+	if w.robots == nil {
+		return true
+	}
+	u, err := url.Parse(l.url)
+	if err != nil {
+		return false
+	}
+	return w.robots.allowed(ctx, w.client, w.userAgent, u)
+}
+
+// nextFrontier picks up to budget unvisited links, best anchor match first
+func nextFrontier(links []pageLink, terms []string, visited map[string]bool, budget int) []string {
+	type candidate struct {
+		url   string
+		score float64
+	}
+
+	var candidates []candidate
+	for _, l := range links {
+		key := normalizeLink(l.url)
+		if visited[key] {
+			continue
+		}
+		visited[key] = true
+		candidates = append(candidates, candidate{
+			url:   l.url,
+			score: linkScore(l, terms),
+		})
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].score > candidates[j].score
+	})
+
+	var next []string
+	for _, c := range candidates[:min(budget, len(candidates))] {
+		next = append(next, c.url)
+	}
+	// Links not taken can still be reached from another page later
+	for _, c := range candidates[min(budget, len(candidates)):] {
+		delete(visited, normalizeLink(c.url))
+	}
+	return next
+}
+
+// linkScore is the fraction of query terms found in the anchor text or URL path
+func linkScore(l pageLink, terms []string) float64 {
+	if len(terms) == 0 {
+		return 0
+	}
+
+	words := make(map[string]bool)
+	for _, t := range splitWords(l.anchor) {
+		words[t] = true
+	}
+	if u, err := url.Parse(l.url); err == nil {
+		for _, t := range splitWords(u.Path) {
+			words[t] = true
+		}
+	}
+
+	hits := 0
+	for _, t := range terms {
+		if words[t] {
+			hits++
+		}
+	}
+	return float64(hits) / float64(len(terms))
+}
+
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true,
+	"be": true, "by": true, "can": true, "do": true, "does": true, "for": true,
+	"from": true, "how": true, "i": true, "in": true, "is": true, "it": true,
+	"of": true, "on": true, "or": true, "the": true, "to": true, "what": true,
+	"when": true, "where": true, "which": true, "who": true, "why": true,
+	"with": true,
+}
+
+func queryTerms(query string) []string {
+	var terms []string
+	for _, t := range splitWords(query) {
+		if !stopWords[t] {
+			terms = append(terms, t)
+		}
+	}
+	return terms
+}
+
+func splitWords(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// skippedExtensions are links that never lead to HTML pages
+var skippedExtensions = map[string]bool{
+	".pdf": true, ".zip": true, ".gz": true, ".tar": true, ".png": true,
+	".jpg": true, ".jpeg": true, ".gif": true, ".svg": true, ".webp": true,
+	".mp4": true, ".mp3": true, ".css": true, ".js": true, ".xml": true,
+}
+
+// sameSiteLinks returns the http(s) links on doc that stay on base's site
+func sameSiteLinks(doc *goquery.Document, base *url.URL) []pageLink {
+	var links []pageLink
+	seen := make(map[string]bool)
+
+	doc.Find("a[href]").Each(func(_ int, s *goquery.Selection) {
+		href, _ := s.Attr("href")
+		u, err := base.Parse(strings.TrimSpace(href))
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return
+		}
+		u.Fragment = ""
+		if siteOf(u.Hostname()) != siteOf(base.Hostname()) {
+			return
+		}
+		if skippedExtensions[strings.ToLower(path.Ext(u.Path))] {
+			return
+		}
+
+		key := normalizeLink(u.String())
+		if seen[key] || key == normalizeLink(base.String()) {
+			return
+		}
+		seen[key] = true
+		links = append(links, pageLink{
+			url:    u.String(),
+			anchor: strings.Join(strings.Fields(s.Text()), " "),
+		})
+	})
+	return links
+}
+
+func siteOf(host string) string {
+	return strings.TrimPrefix(strings.ToLower(host), "www.")
+}
+
+// normalizeLink maps URLs that point at the same page to the same key
+func normalizeLink(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+	u.Fragment = ""
+	u.Host = siteOf(u.Host)
+	u.Scheme = "https"
+	u.Path = strings.TrimSuffix(u.Path, "/")
+	return u.String()
+}
+
+// hostLimiter spaces out requests to the same host
+type hostLimiter struct {
+	mu   sync.Mutex
+	next map[string]time.Time
+}
+
+func newHostLimiter() *hostLimiter {
+	return &hostLimiter{
+		next: make(map[string]time.Time),
+	}
+}
+
+// wait blocks until a request to host is allowed, reserving the next slot
+func (hl *hostLimiter) wait(ctx context.Context, host string, delay time.Duration) error {
+	if hl == nil || delay <= 0 {
+		return nil
+	}
+
+	hl.mu.Lock()
+	now := time.Now()
+	at := hl.next[host]
+	if at.Before(now) {
+		at = now
+	}
+	hl.next[host] = at.Add(delay)
+	hl.mu.Unlock()
+
+	timer := time.NewTimer(time.Until(at))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
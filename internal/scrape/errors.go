@@ -0,0 +1,79 @@
+package scrape
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+// ErrorKind categorizes why a URL could not be scraped
+type ErrorKind string
+
+const (
+	ErrKindTimeout          ErrorKind = "timeout"
+	ErrKindHTTPStatus       ErrorKind = "http_status"
+	ErrKindRobotsDisallowed ErrorKind = "robots_disallowed"
+	ErrKindTooShort         ErrorKind = "too_short"
+	ErrKindUnsupportedType  ErrorKind = "unsupported_type"
+	ErrKindFetch            ErrorKind = "fetch"
+	ErrKindParse            ErrorKind = "parse"
+)
+
+// ScrapeError is the error recorded in ScrapedContent.Error for a failed URL
+type ScrapeError struct {
+	Kind       ErrorKind
+	URL        string
+	StatusCode int
+	Err        error
+
+	// retryAfter is the server's Retry-After hint, if any
+	retryAfter time.Duration
+}
+
+func (e *ScrapeError) Error() string {
+	switch e.Kind {
+	case ErrKindHTTPStatus:
+		return fmt.Sprintf("%s: unexpected status %d", e.URL, e.StatusCode)
+	case ErrKindRobotsDisallowed:
+		return fmt.Sprintf("%s: disallowed by robots.txt", e.URL)
+	}
+	if e.Err == nil {
+		return fmt.Sprintf("%s: %s", e.URL, e.Kind)
+	}
+	return fmt.Sprintf("%s: %s: %v", e.URL, e.Kind, e.Err)
+}
+
+func (e *ScrapeError) Unwrap() error {
+	return e.Err
+}
+
+// KindOf returns the ErrorKind of err, or "" if err is not a *ScrapeError
+func KindOf(err error) ErrorKind {
+	var se *ScrapeError
+	if errors.As(err, &se) {
+		return se.Kind
+	}
+	return ""
+}
+
+func newScrapeError(kind ErrorKind, url string, err error) *ScrapeError {
+	return &ScrapeError{
+		Kind: kind,
+		URL:  url,
+		Err:  err,
+	}
+}
+
+// fetchErrorKind tells timeouts apart from other transport failures
+func fetchErrorKind(err error) ErrorKind {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrKindTimeout
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return ErrKindTimeout
+	}
+	return ErrKindFetch
+}
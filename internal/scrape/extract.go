@@ -0,0 +1,183 @@
+package scrape
+
+import (
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// DefaultExtractors understand Stack Exchange sites, GitHub issues and pull
+// requests, and Discourse forums
+var DefaultExtractors = []Extractor{
+	stackExchangeExtractor{},
+	githubIssueExtractor{},
+	discourseExtractor{},
+}
+
+// extractPosts returns the posts of the page from the first extractor that
+// matches it
+func extractPosts(extractors []Extractor, u *url.URL, doc *goquery.Document) []Post {
+	for _, ex := range extractors {
+		if ex.Match(u, doc) {
+			return ex.Extract(doc)
+		}
+	}
+	return nil
+}
+
+var stackExchangeHosts = []string{"stackoverflow.com", "stackexchange.com", "superuser.com", "serverfault.com", "askubuntu.com", "mathoverflow.net"}
+
+type stackExchangeExtractor struct{}
+
+func (stackExchangeExtractor) Match(u *url.URL, doc *goquery.Document) bool {
+	return hostIn(u, stackExchangeHosts) &&
+		strings.HasPrefix(u.Path, "/questions/") &&
+		doc.Find("#question").Length() > 0
+}
+
+func (stackExchangeExtractor) Extract(doc *goquery.Document) []Post {
+	var posts []Post
+	add := func(kind PostKind, s *goquery.Selection, accepted bool) {
+		score, ok := s.Attr("data-score")
+		if !ok {
+			score = s.Find(".js-vote-count").First().AttrOr("data-value", s.Find(".js-vote-count").First().Text())
+		}
+		posts = appendPost(posts, Post{
+			Kind:     kind,
+			Author:   collapseSpace(s.Find(`.post-signature .user-details a[href*="/users/"]`).Last().Text()),
+			Score:    parseScore(score),
+			Accepted: accepted,
+			Content:  postText(s.Find(".js-post-body, .s-prose").First()),
+		})
+		s.Find(".comment").Each(func(_ int, c *goquery.Selection) {
+			posts = appendPost(posts, Post{
+				Kind:    PostComment,
+				Author:  collapseSpace(c.Find(".comment-user").First().Text()),
+				Score:   parseScore(c.Find(".comment-score").First().Text()),
+				Content: collapseSpace(c.Find(".comment-copy").First().Text()),
+			})
+		})
+	}
+
+	add(PostQuestion, doc.Find("#question"), false)
+	doc.Find(".answer").Each(func(_ int, a *goquery.Selection) {
+		accepted := a.HasClass("accepted-answer") || a.HasClass("js-accepted-answer") ||
+			a.AttrOr("itemprop", "") == "acceptedAnswer"
+		add(PostAnswer, a, accepted)
+	})
+	return posts
+}
+
+var githubThreadPath = regexp.MustCompile(`^/[^/]+/[^/]+/(issues|pull|discussions)/\d+`)
+
+type githubIssueExtractor struct{}
+
+func (githubIssueExtractor) Match(u *url.URL, doc *goquery.Document) bool {
+	return strings.EqualFold(u.Hostname(), "github.com") && githubThreadPath.MatchString(u.Path)
+}
+
+func (githubIssueExtractor) Extract(doc *goquery.Document) []Post {
+	var posts []Post
+	doc.Find(".timeline-comment").Each(func(i int, c *goquery.Selection) {
+		kind := PostAnswer
+		if i == 0 {
+			kind = PostQuestion
+		}
+		reactions := func(name string) int {
+			return parseScore(c.Find(`[value^="` + name + `"] .js-discussion-reaction-group-count`).First().Text())
+		}
+		posts = appendPost(posts, Post{
+			Kind:     kind,
+			Author:   collapseSpace(c.Find(".author").First().Text()),
+			Score:    reactions("THUMBS_UP") - reactions("THUMBS_DOWN"),
+			Accepted: c.Closest(".discussion-timeline-item-answer, [data-answer]").Length() > 0,
+			Content:  postText(c.Find(".comment-body").First()),
+		})
+	})
+	return posts
+}
+
+type discourseExtractor struct{}
+
+// Match recognizes Discourse by its generator tag, since forums run on
+// their own domains
+func (discourseExtractor) Match(u *url.URL, doc *goquery.Document) bool {
+	generator := doc.Find(`meta[name="generator"]`).AttrOr("content", "")
+	return strings.HasPrefix(generator, "Discourse") && doc.Find(".crawler-post").Length() > 0
+}
+
+func (discourseExtractor) Extract(doc *goquery.Document) []Post {
+	var posts []Post
+	doc.Find(".crawler-post").Each(func(i int, c *goquery.Selection) {
+		kind := PostAnswer
+		if i == 0 {
+			kind = PostQuestion
+		}
+		author := c.Find(`[itemprop="author"] [itemprop="name"]`).First().Text()
+		if author == "" {
+			author = c.Find(".creator a").First().Text()
+		}
+		likes := c.Find(`[itemprop="userInteractionCount"]`).First().AttrOr("content", "")
+		if likes == "" {
+			likes = c.Find(".post-likes").First().Text()
+		}
+		posts = appendPost(posts, Post{
+			Kind:     kind,
+			Author:   collapseSpace(author),
+			Score:    parseScore(likes),
+			Accepted: c.AttrOr("itemprop", "") == "acceptedAnswer" || c.HasClass("accepted-answer"),
+			Content:  postText(c.Find(`.post[itemprop="text"], .post`).First()),
+		})
+	})
+	return posts
+}
+
+// appendPost drops posts without text, e.g. deleted ones
+func appendPost(posts []Post, p Post) []Post {
+	if p.Content == "" {
+		return posts
+	}
+	return append(posts, p)
+}
+
+func hostIn(u *url.URL, hosts []string) bool {
+	host := strings.ToLower(u.Hostname())
+	return slices.ContainsFunc(hosts, func(h string) bool {
+		return host == h || strings.HasSuffix(host, "."+h)
+	})
+}
+
+// postText is the text of a post with its line breaks, so code survives
+func postText(s *goquery.Selection) string {
+	var lines []string
+	blank := false
+	for _, line := range strings.Split(s.Text(), "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		if strings.TrimSpace(line) == "" {
+			blank = len(lines) > 0
+			continue
+		}
+		if blank {
+			lines = append(lines, "")
+			blank = false
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func collapseSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+var firstNumber = regexp.MustCompile(`-?\d+`)
+
+// parseScore reads the first number in a vote or like count, e.g. "3 Likes"
+func parseScore(s string) int {
+	n, _ := strconv.Atoi(firstNumber.FindString(strings.ReplaceAll(s, ",", "")))
+	return n
+}
@@ -0,0 +1,309 @@
+package scrape
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// minTextLength is the shortest body text accepted as page content
+const minTextLength = 100
+
+// httpRenderer asks a remote rendering service for the page HTML. The
+// service is called as GET endpoint?url=<page url> and must reply with the
+// rendered HTML.
+type httpRenderer struct {
+	client   *http.Client
+	endpoint string
+}
+
+func NewHTTPRenderer(client *http.Client, endpoint string) Renderer {
+	return &httpRenderer{
+		client:   client,
+		endpoint: endpoint,
+	}
+}
+
+func (h *httpRenderer) Render(ctx context.Context, pageURL string) (string, error) {
+	u, err := url.Parse(h.endpoint)
+	if err != nil {
+		return "", fmt.Errorf("error parsing renderer endpoint: %w", err)
+	}
+	v := u.Query()
+	v.Set("url", pageURL)
+	u.RawQuery = v.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return "", fmt.Errorf("error creating render request: %w", err)
+	}
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("error calling renderer: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("renderer returned status %d", resp.StatusCode)
+	}
+	b, err := io.ReadAll(io.LimitReader(resp.Body, 16<<20))
+	if err != nil {
+		return "", fmt.Errorf("error reading rendered page: %w", err)
+	}
+	return string(b), nil
+}
+
+// clientRendered reports whether a page probably builds its content with
+// JavaScript: little text plus an empty app mount point, a noscript
+// warning, or script bundles far outweighing the text. It must be called
+// before scripts are stripped from doc.
+func clientRendered(doc *goquery.Document, text string) bool {
+	hints := 0
+
+	for _, sel := range []string{"#root", "#app", "#__next", "#__nuxt", "[data-reactroot]", "app-root"} {
+		mount := doc.Find(sel).First()
+		if mount.Length() > 0 && len(strings.TrimSpace(mount.Text())) < minTextLength {
+			hints++
+			break
+		}
+	}
+
+	doc.Find("noscript").EachWithBreak(func(_ int, s *goquery.Selection) bool {
+		if strings.Contains(strings.ToLower(s.Text()), "javascript") {
+			hints++
+			return false
+		}
+		return true
+	})
+
+	scriptBytes := 0
+	external := 0
+	doc.Find("script").Each(func(_ int, s *goquery.Selection) {
+		if _, ok := s.Attr("src"); ok {
+			external++
+		}
+		scriptBytes += len(s.Text())
+	})
+	if external >= 3 || scriptBytes > 10*max(len(text), minTextLength) {
+		hints++
+	}
+
+	if doc.Find("script#__NEXT_DATA__").Length() > 0 {
+		hints++
+	}
+
+	switch {
+	case len(text) < minTextLength:
+		return hints >= 1
+	case len(text) < 5*minTextLength:
+		return hints >= 2
+	}
+	return false
+}
+
+// pageText returns the visible text of doc, ignoring scripts and styles
+func pageText(doc *goquery.Document) string {
+	body := doc.Find("body").Clone()
+	body.Find("script, style, noscript, template").Remove()
+	return strings.Join(strings.Fields(body.Text()), " ")
+}
+
+// pageTitle prefers the document title and falls back to og:title
+func pageTitle(doc *goquery.Document) string {
+	if title := strings.Join(strings.Fields(doc.Find("title").First().Text()), " "); title != "" {
+		return title
+	}
+	title, _ := doc.Find(`meta[property="og:title"]`).Attr("content")
+	return strings.TrimSpace(title)
+}
+
+// pageLang is the declared language of the page, e.g. "en-US"
+func pageLang(doc *goquery.Document) string {
+	if lang := strings.TrimSpace(doc.Find("html").AttrOr("lang", "")); lang != "" {
+		return lang
+	}
+	if lang := doc.Find(`meta[http-equiv="content-language" i]`).AttrOr("content", ""); lang != "" {
+		return strings.TrimSpace(strings.Split(lang, ",")[0])
+	}
+	return strings.TrimSpace(doc.Find(`meta[property="og:locale"]`).AttrOr("content", ""))
+}
+
+// publishedSelectors locate the publication date in common page metadata
+var publishedSelectors = []struct {
+	selector string
+	attr     string
+}{
+	{`meta[property="article:published_time"]`, "content"},
+	{`meta[name="date"], meta[name="pubdate"], meta[name="publish-date"]`, "content"},
+	{`meta[itemprop="datePublished"]`, "content"},
+	{`[itemprop="datePublished"]`, "datetime"},
+	{`time[datetime]`, "datetime"},
+}
+
+// pagePublished is the publication date of the page, or the zero time when
+// it declares none
+func pagePublished(doc *goquery.Document) time.Time {
+	for _, ps := range publishedSelectors {
+		if t, ok := parsePublished(doc.Find(ps.selector).First().AttrOr(ps.attr, "")); ok {
+			return t
+		}
+	}
+
+	var published time.Time
+	doc.Find(`script[type="application/ld+json"]`).EachWithBreak(func(_ int, s *goquery.Selection) bool {
+		var v any
+		if err := json.Unmarshal([]byte(s.Text()), &v); err != nil {
+			return true
+		}
+		published = ldPublished(v)
+		return published.IsZero()
+	})
+	return published
+}
+
+// ldPublished finds the first datePublished in JSON-LD data
+func ldPublished(v any) time.Time {
+	switch v := v.(type) {
+	case map[string]any:
+		if s, ok := v["datePublished"].(string); ok {
+			if t, ok := parsePublished(s); ok {
+				return t
+			}
+		}
+		for _, k := range slices.Sorted(maps.Keys(v)) {
+			if t := ldPublished(v[k]); !t.IsZero() {
+				return t
+			}
+		}
+	case []any:
+		for _, e := range v {
+			if t := ldPublished(e); !t.IsZero() {
+				return t
+			}
+		}
+	}
+	return time.Time{}
+}
+
+func parsePublished(s string) (time.Time, bool) {
+	s = strings.TrimSpace(s)
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02", time.RFC1123, time.RFC1123Z} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// chromeElements are stripped from page markup since they carry navigation
+// and layout rather than content. chunk.chromeElements is a copy, for markup
+// that doesn't come from the scraper; keep the two in sync.
+const chromeElements = "script, style, noscript, template, nav, header, footer, aside, form, iframe, svg"
+
+// bodyMarkup returns the body HTML of doc without scripts and page chrome
+func bodyMarkup(doc *goquery.Document) string {
+	body := doc.Find("body").Clone()
+	body.Find(chromeElements).Remove()
+	html, err := goquery.OuterHtml(body)
+	if err != nil {
+		return ""
+	}
+	return html
+}
+
+// embeddedText pulls readable text out of JSON-LD blocks and Next.js page
+// data, which client-rendered pages often ship alongside an empty body
+func embeddedText(doc *goquery.Document) string {
+	var parts []string
+	seen := make(map[string]bool)
+	add := func(s string) {
+		s = strings.Join(strings.Fields(s), " ")
+		if s != "" && !seen[s] {
+			seen[s] = true
+			parts = append(parts, s)
+		}
+	}
+
+	doc.Find(`script[type="application/ld+json"]`).Each(func(_ int, s *goquery.Selection) {
+		var v any
+		if err := json.Unmarshal([]byte(s.Text()), &v); err != nil {
+			return
+		}
+		collectLDText(v, add)
+	})
+
+	doc.Find("script#__NEXT_DATA__").Each(func(_ int, s *goquery.Selection) {
+		var v any
+		if err := json.Unmarshal([]byte(s.Text()), &v); err != nil {
+			return
+		}
+		collectProse(v, add)
+	})
+
+	return strings.Join(parts, "\n")
+}
+
+// ldTextFields are the schema.org properties that carry readable text
+var ldTextFields = []string{"headline", "name", "description", "articleBody", "text", "reviewBody"}
+
+func collectLDText(v any, add func(string)) {
+	switch t := v.(type) {
+	case []any:
+		for _, e := range t {
+			collectLDText(e, add)
+		}
+	case map[string]any:
+		for _, f := range ldTextFields {
+			if s, ok := t[f].(string); ok {
+				add(stripTags(s))
+			}
+		}
+		for _, k := range slices.Sorted(maps.Keys(t)) {
+			switch e := t[k]; e.(type) {
+			case map[string]any, []any:
+				if k != "@context" {
+					collectLDText(e, add)
+				}
+			}
+		}
+	}
+}
+
+// collectProse walks arbitrary JSON and keeps strings that look like prose
+func collectProse(v any, add func(string)) {
+	switch t := v.(type) {
+	case []any:
+		for _, e := range t {
+			collectProse(e, add)
+		}
+	case map[string]any:
+		for _, k := range slices.Sorted(maps.Keys(t)) {
+			collectProse(t[k], add)
+		}
+	case string:
+		s := stripTags(t)
+		if len(s) >= 40 && strings.Count(s, " ") >= 5 && !strings.HasPrefix(s, "http") {
+			add(s)
+		}
+	}
+}
+
+func stripTags(s string) string {
+	if !strings.Contains(s, "<") {
+		return s
+	}
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(s))
+	if err != nil {
+		return s
+	}
+	return doc.Text()
+}
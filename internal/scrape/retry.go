@@ -0,0 +1,175 @@
+package scrape
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// RetryPolicy controls how transient fetch failures are retried. Delays grow
+// exponentially from BaseDelay, are jittered, and never exceed MaxDelay.
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	BaseDelay:   500 * time.Millisecond,
+	MaxDelay:    10 * time.Second,
+}
+
+// backoff returns the jittered delay before the given retry (1-based)
+func (rp RetryPolicy) backoff(retry int) time.Duration {
+	delay := rp.BaseDelay << (retry - 1)
+	if delay <= 0 || delay > rp.MaxDelay {
+		delay = rp.MaxDelay
+	}
+	// Jitter within [delay/2, delay]
+	half := delay / 2
+	if half <= 0 {
+		return delay
+	}
+	return half + rand.N(half+1)
+}
+
+// fetch performs a single GET. Error statuses are returned as *ScrapeError
+// with the response body already closed.
+func (w *webScraper) fetch(ctx context.Context, rawURL string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, newScrapeError(ErrKindFetch, rawURL, fmt.Errorf("error creating request: %w", err))
+	}
+
+	if err := w.limiter.wait(ctx, req.URL.Host, w.hostDelay(req.URL)); err != nil {
+		return nil, newScrapeError(fetchErrorKind(err), rawURL, err)
+	}
+
+	req.Header.Set("User-Agent", w.userAgent)
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return nil, newScrapeError(fetchErrorKind(err), rawURL, fmt.Errorf("error fetching URL: %w", err))
+	}
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		resp.Body.Close()
+		return nil, &ScrapeError{
+			Kind:       ErrKindHTTPStatus,
+			URL:        rawURL,
+			StatusCode: resp.StatusCode,
+			retryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+		}
+	}
+	return resp, nil
+}
+
+// hostDelay is the politeness delay for u's host: the configured one or the
+// host's robots.txt Crawl-delay, whichever is longer
+func (w *webScraper) hostDelay(u *url.URL) time.Duration {
+	delay := w.crawl.HostDelay
+	if w.robots != nil {
+		delay = max(delay, w.robots.crawlDelay(u))
+	}
+	return delay
+}
+
+// fetchWithRetry retries transient failures according to w.retry
+func (w *webScraper) fetchWithRetry(ctx context.Context, rawURL string) (*http.Response, error) {
+	attempts := max(w.retry.MaxAttempts, 1)
+
+	for attempt := 1; ; attempt++ {
+		resp, err := w.fetch(ctx, rawURL)
+		if err == nil || attempt == attempts || !retryable(ctx, err) {
+			return resp, err
+		}
+
+		delay := w.retry.backoff(attempt)
+		var se *ScrapeError
+		if errors.As(err, &se) && se.retryAfter > 0 {
+			// Honour the server's wait, unless it is longer than we're willing to wait
+			if se.retryAfter > w.retry.MaxDelay {
+				return nil, err
+			}
+			delay = se.retryAfter
+		}
+
+		log.Printf("retrying %v in %v (attempt %d/%d): %v", rawURL, delay, attempt+1, attempts, err)
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, newScrapeError(fetchErrorKind(ctx.Err()), rawURL, ctx.Err())
+		}
+	}
+}
+
+// retryable reports whether err is worth another attempt
+func retryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	var se *ScrapeError
+	if !errors.As(err, &se) {
+		return false
+	}
+
+	switch se.Kind {
+	case ErrKindHTTPStatus:
+		switch se.StatusCode {
+		case http.StatusTooManyRequests,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	case ErrKindTimeout:
+		return true
+	case ErrKindFetch:
+		if errors.Is(err, syscall.ECONNRESET) ||
+			errors.Is(err, syscall.ECONNREFUSED) ||
+			errors.Is(err, io.ErrUnexpectedEOF) ||
+			errors.Is(err, io.EOF) {
+			return true
+		}
+		// DNS failures won't fix themselves within a query
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) {
+			return dnsErr.IsTemporary
+		}
+		var opErr *net.OpError
+		return errors.As(err, &opErr)
+	}
+	return false
+}
+
+// parseRetryAfter accepts both delay-seconds and HTTP-date values
+func parseRetryAfter(v string, now time.Time) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		if secs < 0 {
+			return 0
+		}
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if d := t.Sub(now); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
@@ -0,0 +1,158 @@
+package scrape
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// robotsRules holds the Allow/Disallow rules of the "*" group of a robots.txt
+type robotsRules struct {
+	allow      []string
+	disallow   []string
+	crawlDelay time.Duration
+}
+
+// allowed applies the longest matching rule, with Allow winning ties
+func (r *robotsRules) allowed(path string) bool {
+	if r == nil {
+		return true
+	}
+	best := -1
+	ok := true
+	for _, p := range r.disallow {
+		if p != "" && strings.HasPrefix(path, p) && len(p) > best {
+			best = len(p)
+			ok = false
+		}
+	}
+	for _, p := range r.allow {
+		if strings.HasPrefix(path, p) && len(p) >= best {
+			best = len(p)
+			ok = true
+		}
+	}
+	return ok
+}
+
+func parseRobots(r io.Reader) *robotsRules {
+	rules := &robotsRules{}
+	inGroup := false
+	lastWasAgent := false
+
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := sc.Text()
+		if i := strings.Index(line, "#"); i != -1 {
+			line = line[:i]
+		}
+		field, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		field = strings.ToLower(strings.TrimSpace(field))
+		value = strings.TrimSpace(value)
+
+		switch field {
+		case "user-agent":
+			// Consecutive User-agent lines share one group
+			if !lastWasAgent {
+				inGroup = false
+			}
+			if value == "*" {
+				inGroup = true
+			}
+			lastWasAgent = true
+		case "allow":
+			if inGroup {
+				rules.allow = append(rules.allow, value)
+			}
+			lastWasAgent = false
+		case "disallow":
+			if inGroup {
+				rules.disallow = append(rules.disallow, value)
+			}
+			lastWasAgent = false
+		case "crawl-delay":
+			if inGroup {
+				if secs, err := strconv.ParseFloat(value, 64); err == nil && secs > 0 {
+					rules.crawlDelay = time.Duration(secs * float64(time.Second))
+				}
+			}
+			lastWasAgent = false
+		default:
+			lastWasAgent = false
+		}
+	}
+	return rules
+}
+
+// robotsCache fetches robots.txt once per host
+type robotsCache struct {
+	mu    sync.Mutex
+	rules map[string]*robotsRules
+}
+
+func newRobotsCache() *robotsCache {
+	return &robotsCache{
+		rules: make(map[string]*robotsRules),
+	}
+}
+
+func (rc *robotsCache) allowed(ctx context.Context, client *http.Client, ua string, u *url.URL) bool {
+	host := u.Scheme + "://" + u.Host
+
+	rc.mu.Lock()
+	rules, ok := rc.rules[host]
+	rc.mu.Unlock()
+
+	if !ok {
+		rules = fetchRobots(ctx, client, ua, host)
+		rc.mu.Lock()
+		rc.rules[host] = rules
+		rc.mu.Unlock()
+	}
+
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	return rules.allowed(path)
+}
+
+// crawlDelay returns the Crawl-delay of u's host if its robots.txt is cached
+func (rc *robotsCache) crawlDelay(u *url.URL) time.Duration {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	if rules := rc.rules[u.Scheme+"://"+u.Host]; rules != nil {
+		return rules.crawlDelay
+	}
+	return 0
+}
+
+// fetchRobots returns nil (allow everything) when robots.txt is unavailable
+func fetchRobots(ctx context.Context, client *http.Client, ua string, host string) *robotsRules {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, host+"/robots.txt", nil)
+	if err != nil {
+		return nil
+	}
+	req.Header.Set("User-Agent", ua)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil
+	}
+	return parseRobots(io.LimitReader(resp.Body, 512*1024))
+}
@@ -0,0 +1,56 @@
+package scrape
+
+import (
+	"net/url"
+	"sync"
+)
+
+// FailureStats keeps per-domain counts of scrape failures by ErrorKind
+type FailureStats struct {
+	mu       sync.Mutex
+	byDomain map[string]map[ErrorKind]int
+}
+
+func NewFailureStats() *FailureStats {
+	return &FailureStats{
+		byDomain: make(map[string]map[ErrorKind]int),
+	}
+}
+
+// Record counts result if it failed
+func (fs *FailureStats) Record(result ScrapedContent) {
+	if result.Error == nil {
+		return
+	}
+
+	domain := result.URL
+	if u, err := url.Parse(result.URL); err == nil && u.Host != "" {
+		domain = u.Hostname()
+	}
+	kind := KindOf(result.Error)
+	if kind == "" {
+		kind = ErrKindFetch
+	}
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	if fs.byDomain[domain] == nil {
+		fs.byDomain[domain] = make(map[ErrorKind]int)
+	}
+	fs.byDomain[domain][kind]++
+}
+
+// Snapshot returns a copy of the counts
+func (fs *FailureStats) Snapshot() map[string]map[ErrorKind]int {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	out := make(map[string]map[ErrorKind]int, len(fs.byDomain))
+	for domain, kinds := range fs.byDomain {
+		out[domain] = make(map[ErrorKind]int, len(kinds))
+		for k, n := range kinds {
+			out[domain][k] = n
+		}
+	}
+	return out
+}
@@ -4,30 +4,127 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"mime"
 	"net/http"
+	"net/url"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/PuerkitoBio/goquery"
 )
 
 type webScraper struct {
-	client     *http.Client
-	userAgent  string
-	maxWorkers int
+	client      *http.Client
+	userAgent   string
+	maxWorkers  int
+	robots      *robotsCache
+	retry       RetryPolicy
+	fallbackURL string
+	renderer    Renderer
+	crawl       CrawlConfig
+	limiter     *hostLimiter
+	extractors  []Extractor
 }
 
-func NewWebScraper(client *http.Client, ua string, mw int) Scraper {
-	return &webScraper{
+// Option configures optional webScraper behaviour
+type Option func(*webScraper)
+
+// WithRetryPolicy sets how transient failures are retried
+func WithRetryPolicy(rp RetryPolicy) Option {
+	return func(w *webScraper) {
+		w.retry = rp
+	}
+}
+
+// WithFallback sets a base URL, such as an archive or cache mirror, that the
+// page URL is appended to and fetched from when the origin fails
+func WithFallback(baseURL string) Option {
+	return func(w *webScraper) {
+		w.fallbackURL = baseURL
+	}
+}
+
+// WithRenderer sets the Renderer used for pages that look client-rendered
+func WithRenderer(r Renderer) Option {
+	return func(w *webScraper) {
+		w.renderer = r
+	}
+}
+
+// WithCrawl enables following same-site links from scraped pages in Crawl
+func WithCrawl(cfg CrawlConfig) Option {
+	if cfg.MaxPages <= 0 {
+		cfg.MaxPages = DefaultCrawlPages
+	}
+	return func(w *webScraper) {
+		w.crawl = cfg
+	}
+}
+
+// WithExtractors replaces DefaultExtractors, which split forum and Q&A pages
+// into posts
+func WithExtractors(ex ...Extractor) Option {
+	return func(w *webScraper) {
+		w.extractors = ex
+	}
+}
+
+func NewWebScraper(client *http.Client, ua string, mw int, opts ...Option) Scraper {
+	w := &webScraper{
 		client:     client,
 		userAgent:  ua,
 		maxWorkers: mw,
+		robots:     newRobotsCache(),
+		retry:      DefaultRetryPolicy,
+		limiter:    newHostLimiter(),
+		extractors: DefaultExtractors,
 	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	return w
 }
 
 func (w *webScraper) Scrape(ctx context.Context, urls []string) (map[string]ScrapedContent, error) {
 	results := make(map[string]ScrapedContent)
-	resultsMu := sync.Mutex{}
+	failed := 0
+	for result := range w.ScrapeStream(ctx, urls) {
+		results[result.URL] = result
+		if result.Error != nil {
+			failed++
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	log.Printf("scrape finished with %v results, %v failed", len(results)-failed, failed)
+	return results, nil
+}
+
+func (w *webScraper) ScrapeStream(ctx context.Context, urls []string) <-chan ScrapedContent {
+	out := make(chan ScrapedContent, len(urls))
+	go func() {
+		defer close(out)
+		for res := range w.scrapePages(ctx, urls, 0) {
+			out <- res.content
+		}
+	}()
+	return out
+}
+
+// pageResult is a scraped page along with the links found on it
+type pageResult struct {
+	content ScrapedContent
+	links   []pageLink
+}
+
+// scrapePages scrapes urls with the worker pool, emitting results as they
+// are ready
+func (w *webScraper) scrapePages(ctx context.Context, urls []string, depth int) <-chan pageResult {
+	// Buffered so workers never block on a slow consumer
+	out := make(chan pageResult, len(urls))
 
 	// Create worker pool
 	workCh := make(chan string)
@@ -39,72 +136,163 @@ func (w *webScraper) Scrape(ctx context.Context, urls []string) (map[string]Scra
 		go func() {
 			defer wg.Done()
 			for url := range workCh {
-				content, err := w.scrapeURL(ctx, url)
-				resultsMu.Lock()
-				results[url] = ScrapedContent{
-					URL:     url,
-					Content: content,
-					Error:   err,
+				pg, err := w.scrapePage(ctx, url)
+				out <- pageResult{
+					content: ScrapedContent{
+						URL:       url,
+						Content:   pg.text,
+						Markup:    pg.markup,
+						Title:     pg.title,
+						Lang:      pg.lang,
+						Published: pg.published,
+						Error:     err,
+						Depth:     depth,
+						Posts:     pg.posts,
+					},
+					links: pg.links,
 				}
-				resultsMu.Unlock()
 			}
 		}()
 	}
 
 	// Feed URLs to workers
-	for _, url := range urls {
-		select {
-		case workCh <- url:
-		case <-ctx.Done():
+	go func() {
+		defer func() {
 			close(workCh)
-			return nil, ctx.Err()
+			wg.Wait()
+			close(out)
+		}()
+		for _, url := range urls {
+			select {
+			case workCh <- url:
+			case <-ctx.Done():
+				return
+			}
 		}
-	}
-	close(workCh)
-	wg.Wait()
+	}()
 
-	// Filter out errors
-	validResults := make(map[string]ScrapedContent)
-	for url, result := range results {
-		if result.Error == nil && result.Content != "" {
-			validResults[url] = result
-		}
-	}
+	return out
+}
+
+func (w *webScraper) scrapeURL(ctx context.Context, rawURL string) (string, error) {
+	pg, err := w.scrapePage(ctx, rawURL)
+	return pg.text, err
+}
+
+// page is the extracted content of a single URL
+type page struct {
+	text   string
+	markup string
+	title  string
+	links  []pageLink
+	posts  []Post
 
-	log.Printf("scrape succeeded with %v results", len(validResults))
-	return validResults, nil
+	lang      string
+	published time.Time
 }
 
-func (w *webScraper) scrapeURL(ctx context.Context, url string) (string, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+func (w *webScraper) scrapePage(ctx context.Context, rawURL string) (page, error) {
+	u, err := url.Parse(rawURL)
 	if err != nil {
-		return "", fmt.Errorf("error creating request: %w", err)
+		return page{}, newScrapeError(ErrKindFetch, rawURL, fmt.Errorf("error parsing URL: %w", err))
 	}
 
-	req.Header.Set("User-Agent", w.userAgent)
-	resp, err := w.client.Do(req)
+	if w.robots != nil && !w.robots.allowed(ctx, w.client, w.userAgent, u) {
+		return page{}, newScrapeError(ErrKindRobotsDisallowed, rawURL, nil)
+	}
+
+	resp, err := w.fetchWithRetry(ctx, rawURL)
+	if err != nil && w.fallbackURL != "" && ctx.Err() == nil {
+		log.Printf("fetching %v from fallback after: %v", rawURL, err)
+		if fbResp, fbErr := w.fetchWithRetry(ctx, w.fallbackURL+rawURL); fbErr == nil {
+			resp, err = fbResp, nil
+		}
+	}
 	if err != nil {
-		return "", fmt.Errorf("error fetching URL: %w", err)
+		return page{}, err
+	}
+	defer resp.Body.Close()
+
+	if ct := resp.Header.Get("Content-Type"); !supportedContentType(ct) {
+		return page{}, newScrapeError(ErrKindUnsupportedType, rawURL, fmt.Errorf("content type %q", ct))
 	}
 
 	// Parse HTML
 	doc, err := goquery.NewDocumentFromReader(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("error parsing HTML: %w", err)
+		kind := ErrKindParse
+		if fetchErrorKind(err) == ErrKindTimeout {
+			kind = ErrKindTimeout
+		}
+		return page{}, newScrapeError(kind, rawURL, fmt.Errorf("error parsing HTML: %w", err))
 	}
 
-	err = resp.Body.Close()
-	if err != nil {
-		return "", fmt.Errorf("error closing response body: %w", err)
+	// Extract body text
+	bodyText := pageText(doc)
+	markup := doc
+
+	if clientRendered(doc, bodyText) {
+		if rendered := w.render(ctx, rawURL); rendered != nil && len(pageText(rendered)) > len(bodyText) {
+			bodyText = pageText(rendered)
+			markup = rendered
+		} else if text := embeddedText(doc); len(text) > len(bodyText) {
+			bodyText = text
+			markup = nil
+		}
 	}
 
-	// Extract body text
-	bodyText := doc.Find("body").Text()
-	bodyText = strings.Join(strings.Fields(bodyText), " ")
+	if len(bodyText) < minTextLength {
+		return page{}, newScrapeError(ErrKindTooShort, rawURL, fmt.Errorf("body text too short (%d chars)", len(bodyText)))
+	}
+
+	pg := page{
+		text:      bodyText,
+		title:     pageTitle(doc),
+		lang:      pageLang(doc),
+		published: pagePublished(doc),
+	}
+	if markup != nil {
+		pg.markup = bodyMarkup(markup)
+		pg.posts = extractPosts(w.extractors, u, markup)
+	}
+	if w.crawl.MaxDepth > 0 {
+		pg.links = sameSiteLinks(doc, u)
+	}
+	return pg, nil
+}
+
+// render returns the page rendered by w.renderer, or nil if there is no
+// renderer or rendering failed
+func (w *webScraper) render(ctx context.Context, rawURL string) *goquery.Document {
+	if w.renderer == nil {
+		return nil
+	}
 
-	if len(bodyText) < 100 {
-		return "", fmt.Errorf("body text too short (%d chars)", len(bodyText))
+	html, err := w.renderer.Render(ctx, rawURL)
+	if err != nil {
+		log.Printf("render failed for %v: %v", rawURL, err)
+		return nil
+	}
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		log.Printf("error parsing rendered HTML for %v: %v", rawURL, err)
+		return nil
 	}
+	return doc
+}
 
-	return bodyText, nil
+// supportedContentType accepts HTML and plain text, and a missing header
+func supportedContentType(ct string) bool {
+	if ct == "" {
+		return true
+	}
+	mt, _, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return false
+	}
+	switch mt {
+	case "text/html", "application/xhtml+xml", "text/plain":
+		return true
+	}
+	return false
 }
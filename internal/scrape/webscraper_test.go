@@ -2,8 +2,17 @@ package scrape
 
 import (
 	"context"
+	"fmt"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
 )
 
 func Test_webScraper_scrapeURL(t *testing.T) {
@@ -77,3 +86,519 @@ func Test_webScraper_Scrape(t *testing.T) {
 		})
 	}
 }
+
+func Test_webScraper_scrapeURL_errors(t *testing.T) {
+	longText := strings.Repeat("Some readable paragraph text. ", 10)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/robots.txt", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "User-agent: *\nDisallow: /private\n")
+	})
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "<html><body><p>%s</p></body></html>", longText)
+	})
+	mux.HandleFunc("/private/page", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "<html><body><p>%s</p></body></html>", longText)
+	})
+	mux.HandleFunc("/unavailable", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	mux.HandleFunc("/short", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "<html><body>hi</body></html>")
+	})
+	mux.HandleFunc("/doc.pdf", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/pdf")
+		fmt.Fprint(w, "%PDF-1.4")
+	})
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	tests := []struct {
+		name     string
+		path     string
+		wantKind ErrorKind
+	}{
+		{name: "test ok", path: "/ok", wantKind: ""},
+		{name: "test robots disallowed", path: "/private/page", wantKind: ErrKindRobotsDisallowed},
+		{name: "test http status", path: "/unavailable", wantKind: ErrKindHTTPStatus},
+		{name: "test too short", path: "/short", wantKind: ErrKindTooShort},
+		{name: "test unsupported type", path: "/doc.pdf", wantKind: ErrKindUnsupportedType},
+		{name: "test timeout", path: "/slow", wantKind: ErrKindTimeout},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := webScraper{
+				client:    &http.Client{Timeout: 100 * time.Millisecond},
+				userAgent: "goseek-test",
+				robots:    newRobotsCache(),
+			}
+			_, gotErr := w.scrapeURL(context.Background(), srv.URL+tt.path)
+			if got := KindOf(gotErr); got != tt.wantKind {
+				t.Errorf("scrapeURL() error kind = %q, want %q (err: %v)", got, tt.wantKind, gotErr)
+			}
+		})
+	}
+}
+
+func Test_webScraper_ScrapeStream(t *testing.T) {
+	longText := strings.Repeat("Some readable paragraph text. ", 10)
+	release := make(chan struct{})
+	mux := http.NewServeMux()
+	mux.HandleFunc("/fast/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "<html><body><p>%s</p></body></html>", longText)
+	})
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	defer close(release)
+
+	w := webScraper{
+		client:     &http.Client{},
+		userAgent:  "goseek-test",
+		maxWorkers: 2,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream := w.ScrapeStream(ctx, []string{srv.URL + "/slow", srv.URL + "/fast/1", srv.URL + "/fast/2"})
+
+	// The fast pages arrive while the slow one is still in flight
+	for range 2 {
+		got := <-stream
+		if got.Error != nil {
+			t.Fatalf("ScrapeStream() result %v failed: %v", got.URL, got.Error)
+		}
+		if got.URL == srv.URL+"/slow" {
+			t.Fatal("ScrapeStream() emitted the slow page first")
+		}
+	}
+
+	cancel()
+	got, ok := <-stream
+	if !ok || got.URL != srv.URL+"/slow" || got.Error == nil {
+		t.Fatalf("ScrapeStream() after cancel = %+v, %v, want failed slow page", got, ok)
+	}
+	if _, ok := <-stream; ok {
+		t.Fatal("ScrapeStream() channel not closed after cancel")
+	}
+}
+
+func Test_webScraper_scrapeURL_retry(t *testing.T) {
+	longText := strings.Repeat("Some readable paragraph text. ", 10)
+	var flakyHits, goneHits int
+	mux := http.NewServeMux()
+	mux.HandleFunc("/flaky", func(w http.ResponseWriter, r *http.Request) {
+		flakyHits++
+		if flakyHits < 3 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		fmt.Fprintf(w, "<html><body><p>%s</p></body></html>", longText)
+	})
+	mux.HandleFunc("/gone", func(w http.ResponseWriter, r *http.Request) {
+		goneHits++
+		w.WriteHeader(http.StatusNotFound)
+	})
+	mux.HandleFunc("/mirror/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "<html><body><p>%s</p></body></html>", longText)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	tests := []struct {
+		name     string
+		path     string
+		fallback string
+		hits     *int
+		wantHits int
+		wantErr  bool
+	}{
+		{
+			name:     "test retry transient status",
+			path:     "/flaky",
+			hits:     &flakyHits,
+			wantHits: 3,
+			wantErr:  false,
+		},
+		{
+			name:     "test no retry permanent status",
+			path:     "/gone",
+			hits:     &goneHits,
+			wantHits: 1,
+			wantErr:  true,
+		},
+		{
+			name:     "test fallback",
+			path:     "/gone",
+			fallback: srv.URL + "/mirror/",
+			hits:     &goneHits,
+			wantHits: 2,
+			wantErr:  false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := webScraper{
+				client:    &http.Client{},
+				userAgent: "goseek-test",
+				retry: RetryPolicy{
+					MaxAttempts: 3,
+					BaseDelay:   time.Millisecond,
+					MaxDelay:    10 * time.Millisecond,
+				},
+				fallbackURL: tt.fallback,
+			}
+			_, gotErr := w.scrapeURL(context.Background(), srv.URL+tt.path)
+			if gotErr != nil {
+				if !tt.wantErr {
+					t.Errorf("scrapeURL() failed: %v", gotErr)
+				}
+			} else if tt.wantErr {
+				t.Fatal("scrapeURL() succeeded unexpectedly")
+			}
+			if *tt.hits != tt.wantHits {
+				t.Errorf("scrapeURL() origin hits = %v, want %v", *tt.hits, tt.wantHits)
+			}
+		})
+	}
+}
+
+func Test_parseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		value string
+		want  time.Duration
+	}{
+		{name: "test empty", value: "", want: 0},
+		{name: "test seconds", value: "7", want: 7 * time.Second},
+		{name: "test http date", value: "Wed, 01 Jan 2025 00:00:30 GMT", want: 30 * time.Second},
+		{name: "test past date", value: "Tue, 31 Dec 2024 00:00:00 GMT", want: 0},
+		{name: "test garbage", value: "soon", want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseRetryAfter(tt.value, now); got != tt.want {
+				t.Errorf("parseRetryAfter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+type rendererMock struct {
+	html string
+}
+
+func (r *rendererMock) Render(ctx context.Context, url string) (string, error) {
+	if r.html == "" {
+		return "", fmt.Errorf("renderer unavailable")
+	}
+	return r.html, nil
+}
+
+func Test_webScraper_scrapeURL_clientRendered(t *testing.T) {
+	longText := strings.Repeat("Rendered paragraph text. ", 10)
+	spa := `<html><body><noscript>You need to enable JavaScript to run this app.</noscript><div id="root"></div>
+<script src="/a.js"></script><script src="/b.js"></script><script src="/c.js"></script>%s</body></html>`
+	ldJSON := `<script type="application/ld+json">{"@type":"Article","headline":"Headline","articleBody":"` + longText + `"}</script>`
+	nextData := `<script id="__NEXT_DATA__" type="application/json">{"props":{"pageProps":{"post":{"body":"` + longText + `","url":"https://example.com"}}}}</script>`
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/spa", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, spa, "")
+	})
+	mux.HandleFunc("/spa-ld", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, spa, ldJSON)
+	})
+	mux.HandleFunc("/spa-next", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, spa, nextData)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	tests := []struct {
+		name     string
+		path     string
+		renderer Renderer
+		want     string
+		wantErr  bool
+	}{
+		{
+			name:     "test renderer",
+			path:     "/spa",
+			renderer: &rendererMock{html: "<html><body><p>" + longText + "</p></body></html>"},
+			want:     strings.TrimSpace(longText),
+			wantErr:  false,
+		},
+		{
+			name:     "test ld+json fallback",
+			path:     "/spa-ld",
+			renderer: &rendererMock{},
+			want:     "Headline\n" + strings.TrimSpace(longText),
+			wantErr:  false,
+		},
+		{
+			name:    "test next data fallback",
+			path:    "/spa-next",
+			want:    strings.TrimSpace(longText),
+			wantErr: false,
+		},
+		{
+			name:    "test no fallback",
+			path:    "/spa",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := webScraper{
+				client:    &http.Client{},
+				userAgent: "goseek-test",
+				renderer:  tt.renderer,
+			}
+			got, gotErr := w.scrapeURL(context.Background(), srv.URL+tt.path)
+			if gotErr != nil {
+				if !tt.wantErr {
+					t.Errorf("scrapeURL() failed: %v", gotErr)
+				}
+				return
+			}
+			if tt.wantErr {
+				t.Fatal("scrapeURL() succeeded unexpectedly")
+			}
+			if got != tt.want {
+				t.Errorf("scrapeURL() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_webScraper_Crawl(t *testing.T) {
+	longText := strings.Repeat("Some readable paragraph text. ", 10)
+	var mu sync.Mutex
+	var hit []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/robots.txt", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "User-agent: *\nDisallow: /docs/private\n")
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		hit = append(hit, r.URL.Path)
+		mu.Unlock()
+		fmt.Fprintf(w, `<html><body><p>%s</p>
+<a href="/about">About us</a>
+<a href="/docs/private">Install on Linux (internal)</a>
+<a href="/docs/install-linux#arch">Install on Linux</a>
+<a href="https://elsewhere.example/install">Install guide</a>
+</body></html>`, longText)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	w := webScraper{
+		client:     &http.Client{},
+		userAgent:  "goseek-test",
+		maxWorkers: 2,
+		robots:     newRobotsCache(),
+		crawl: CrawlConfig{
+			MaxDepth: 1,
+			MaxPages: 1,
+		},
+	}
+
+	var got []string
+	for res := range w.Crawl(context.Background(), "how to install on linux", []string{srv.URL + "/overview"}) {
+		if res.Error != nil {
+			t.Fatalf("Crawl() result %v failed: %v", res.URL, res.Error)
+		}
+		got = append(got, fmt.Sprintf("%d %s", res.Depth, strings.TrimPrefix(res.URL, srv.URL)))
+	}
+
+	want := []string{"0 /overview", "1 /docs/install-linux"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Crawl() = %v, want %v", got, want)
+	}
+	for _, p := range hit {
+		if p == "/docs/private" || p == "/about" {
+			t.Errorf("Crawl() fetched %v", p)
+		}
+	}
+}
+
+func Test_extractPosts(t *testing.T) {
+	tests := []struct {
+		name string
+		url  string
+		html string
+		want []Post
+	}{
+		{
+			name: "test stack exchange",
+			url:  "https://unix.stackexchange.com/questions/1/how-to-tail",
+			html: `<div id="question" class="question" data-score="12">
+				<div class="js-post-body">How do I follow a log file?</div>
+				<div class="post-signature owner"><div class="user-details"><a href="/users/1/ann">ann</a></div></div>
+				<ul><li class="comment"><span class="comment-score">3</span><span class="comment-copy">Which OS?</span><a class="comment-user">bob</a></li></ul>
+			</div>
+			<div class="answer" data-score="4"><div class="js-post-body">Use less +F.</div>
+				<div class="post-signature"><div class="user-details"><a href="/users/2/carl">carl</a></div></div></div>
+			<div class="answer accepted-answer" data-score="40"><div class="js-post-body">Use
+tail -f file.log</div>
+				<div class="post-signature"><div class="user-details"><a href="/users/3/dee">dee</a></div></div></div>`,
+			want: []Post{
+				{Kind: PostQuestion, Author: "ann", Score: 12, Content: "How do I follow a log file?"},
+				{Kind: PostComment, Author: "bob", Score: 3, Content: "Which OS?"},
+				{Kind: PostAnswer, Author: "carl", Score: 4, Content: "Use less +F."},
+				{Kind: PostAnswer, Author: "dee", Score: 40, Accepted: true, Content: "Use\ntail -f file.log"},
+			},
+		},
+		{
+			name: "test github issue",
+			url:  "https://github.com/owner/repo/issues/7",
+			html: `<div class="timeline-comment"><a class="author">ann</a><div class="comment-body">Crash on start</div>
+				<button value="THUMBS_UP react"><span class="js-discussion-reaction-group-count">5</span></button></div>
+			<div class="timeline-comment"><a class="author">bob</a><div class="comment-body">Fixed in v2</div></div>`,
+			want: []Post{
+				{Kind: PostQuestion, Author: "ann", Score: 5, Content: "Crash on start"},
+				{Kind: PostAnswer, Author: "bob", Content: "Fixed in v2"},
+			},
+		},
+		{
+			name: "test discourse",
+			url:  "https://forum.example.com/t/upgrade-fails/42",
+			html: `<meta name="generator" content="Discourse 3.2">
+			<div class="topic-body crawler-post"><span class="creator"><a>ann</a></span>
+				<div class="post" itemprop="text">Upgrade fails</div></div>
+			<div class="topic-body crawler-post" itemprop="acceptedAnswer"><span class="creator"><a>bob</a></span>
+				<div class="post" itemprop="text">Clear the cache first</div><span class="post-likes">3 Likes</span></div>`,
+			want: []Post{
+				{Kind: PostQuestion, Author: "ann", Content: "Upgrade fails"},
+				{Kind: PostAnswer, Author: "bob", Score: 3, Accepted: true, Content: "Clear the cache first"},
+			},
+		},
+		{
+			name: "test unmatched page",
+			url:  "https://example.com/questions/1",
+			html: `<div id="question"><div class="js-post-body">Not a Q&A site</div></div>`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, _ := url.Parse(tt.url)
+			doc, err := goquery.NewDocumentFromReader(strings.NewReader(tt.html))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := extractPosts(DefaultExtractors, u, doc)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("extractPosts() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_pageMetadata(t *testing.T) {
+	tests := []struct {
+		name          string
+		html          string
+		wantLang      string
+		wantPublished time.Time
+	}{
+		{
+			name:          "test meta tags",
+			html:          `<html lang="en-US"><head><meta property="article:published_time" content="2025-04-02T10:30:00Z"></head><body></body></html>`,
+			wantLang:      "en-US",
+			wantPublished: time.Date(2025, 4, 2, 10, 30, 0, 0, time.UTC),
+		},
+		{
+			name:          "test json-ld and time element",
+			html:          `<html><head><meta property="og:locale" content="de_DE"><script type="application/ld+json">{"@graph": [{"@type": "Article", "datePublished": "2024-12-24"}]}</script></head><body></body></html>`,
+			wantLang:      "de_DE",
+			wantPublished: time.Date(2024, 12, 24, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:          "test time element",
+			html:          `<html><body><time datetime="2023-01-05">Jan 5</time></body></html>`,
+			wantPublished: time.Date(2023, 1, 5, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "test undated",
+			html: `<html><body><p>No metadata</p></body></html>`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := goquery.NewDocumentFromReader(strings.NewReader(tt.html))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := pageLang(doc); got != tt.wantLang {
+				t.Errorf("pageLang() = %q, want %q", got, tt.wantLang)
+			}
+			if got := pagePublished(doc); !got.Equal(tt.wantPublished) {
+				t.Errorf("pagePublished() = %v, want %v", got, tt.wantPublished)
+			}
+		})
+	}
+}
+
+func TestWithCrawl(t *testing.T) {
+	tests := []struct {
+		name  string
+		pages int
+		want  int
+	}{
+		{name: "test set", pages: 5, want: 5},
+		{name: "test unset", pages: 0, want: DefaultCrawlPages},
+		{name: "test negative", pages: -1, want: DefaultCrawlPages},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := NewWebScraper(&http.Client{}, "goseek-test", 1, WithCrawl(CrawlConfig{MaxDepth: 1, MaxPages: tt.pages})).(*webScraper)
+			if w.crawl.MaxPages != tt.want {
+				t.Errorf("WithCrawl() MaxPages = %v, want %v", w.crawl.MaxPages, tt.want)
+			}
+		})
+	}
+}
+
+func Test_webScraper_hostDelay(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/robots.txt", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "User-agent: *\nCrawl-delay: 2\n")
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	u, _ := url.Parse(srv.URL + "/page")
+
+	tests := []struct {
+		name      string
+		hostDelay time.Duration
+		want      time.Duration
+	}{
+		{name: "test robots longer", hostDelay: time.Second, want: 2 * time.Second},
+		{name: "test configured longer", hostDelay: 3 * time.Second, want: 3 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := webScraper{
+				client:    &http.Client{},
+				userAgent: "goseek-test",
+				robots:    newRobotsCache(),
+				crawl:     CrawlConfig{HostDelay: tt.hostDelay},
+			}
+			w.robots.allowed(context.Background(), w.client, w.userAgent, u)
+			if got := w.hostDelay(u); got != tt.want {
+				t.Errorf("hostDelay() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
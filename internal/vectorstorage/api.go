@@ -4,5 +4,9 @@ import "context"
 
 type VectorStore interface {
 	UpsertRecords(ctx context.Context, records any, ns string) error
-	SearchTopK(ctx context.Context, query string, k int, ns string) (any, error)
+	// SearchTopK returns the k records in ns most similar to query that
+	// match filter; the zero Filter matches every record
+	SearchTopK(ctx context.Context, query string, k int, ns string, filter Filter) (any, error)
+	// FetchRecords looks records up by ID, e.g. the parents of matched chunks
+	FetchRecords(ctx context.Context, ids []string, ns string) (any, error)
 }
@@ -0,0 +1,217 @@
+package vectorstorage
+
+import (
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Filter restricts a search to records whose metadata matches every set
+// field. Records are expected to carry the fields written by RecordMeta.
+// Records without a published date never match a date range.
+type Filter struct {
+	// Domains match a link's host or any of its parent domains
+	Domains      []string
+	After        time.Time
+	Before       time.Time
+	Langs        []string
+	ContentTypes []string
+	Engines      []string
+}
+
+// Metadata fields used by Filter
+const (
+	FieldDomains     = "domains"
+	FieldPublished   = "published"
+	FieldLang        = "lang"
+	FieldContentType = "content_type"
+	FieldEngine      = "engine"
+)
+
+// Engines that find the pages of a record
+const (
+	EngineGoogle = "google"
+	// EngineCrawl marks pages reached by following links from the results
+	EngineCrawl = "crawl"
+)
+
+// RecordMeta returns the filterable metadata of a record for the page at
+// link. Unknown values are left out.
+func RecordMeta(link string, published time.Time, lang string, contentType string, engine string) map[string]any {
+	meta := map[string]any{
+		FieldDomains: DomainsOf(link),
+	}
+	if !published.IsZero() {
+		meta[FieldPublished] = published.Unix()
+	}
+	if lang != "" {
+		meta[FieldLang] = normalizeLang(lang)
+	}
+	if contentType != "" {
+		meta[FieldContentType] = contentType
+	}
+	if engine != "" {
+		meta[FieldEngine] = engine
+	}
+	return meta
+}
+
+// DomainsOf returns the host of link and its parent domains, e.g.
+// "docs.python.org" and "python.org", so a domain filter matches subdomains
+// with an exact comparison
+func DomainsOf(link string) []string {
+	u, err := url.Parse(link)
+	if err != nil || u.Hostname() == "" {
+		return []string{}
+	}
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	labels := strings.Split(host, ".")
+	domains := []string{host}
+	for i := 1; i < len(labels)-1; i++ {
+		domains = append(domains, strings.Join(labels[i:], "."))
+	}
+	return domains
+}
+
+// normalizeLang keeps the primary language of a tag, e.g. "en" of "en-US"
+func normalizeLang(lang string) string {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if i := strings.IndexAny(lang, "-_"); i != -1 {
+		lang = lang[:i]
+	}
+	return lang
+}
+
+// Empty reports whether f matches every record
+func (f Filter) Empty() bool {
+	return len(f.Domains) == 0 && f.After.IsZero() && f.Before.IsZero() &&
+		len(f.Langs) == 0 && len(f.ContentTypes) == 0 && len(f.Engines) == 0
+}
+
+// Pinecone returns f as a Pinecone metadata filter, or nil if f is empty
+func (f Filter) Pinecone() *map[string]any {
+	var clauses []any
+	in := func(field string, values []string) {
+		if len(values) > 0 {
+			clauses = append(clauses, map[string]any{field: map[string]any{"$in": toAny(values)}})
+		}
+	}
+	in(FieldDomains, f.normalizedDomains())
+	in(FieldLang, f.normalizedLangs())
+	in(FieldContentType, f.ContentTypes)
+	in(FieldEngine, f.Engines)
+
+	if !f.After.IsZero() || !f.Before.IsZero() {
+		published := map[string]any{}
+		if !f.After.IsZero() {
+			published["$gte"] = f.After.Unix()
+		}
+		if !f.Before.IsZero() {
+			published["$lt"] = f.Before.Unix()
+		}
+		clauses = append(clauses, map[string]any{FieldPublished: published})
+	}
+
+	switch len(clauses) {
+	case 0:
+		return nil
+	case 1:
+		m := clauses[0].(map[string]any)
+		return &m
+	}
+	return &map[string]any{"$and": clauses}
+}
+
+// Match evaluates f against the metadata of a record, for local stores
+func (f Filter) Match(meta map[string]any) bool {
+	if d := f.normalizedDomains(); len(d) > 0 && !anyIn(StringsOf(meta[FieldDomains]), d) {
+		return false
+	}
+	if l := f.normalizedLangs(); len(l) > 0 && !anyIn(StringsOf(meta[FieldLang]), l) {
+		return false
+	}
+	if len(f.ContentTypes) > 0 && !anyIn(StringsOf(meta[FieldContentType]), f.ContentTypes) {
+		return false
+	}
+	if len(f.Engines) > 0 && !anyIn(StringsOf(meta[FieldEngine]), f.Engines) {
+		return false
+	}
+
+	if !f.After.IsZero() || !f.Before.IsZero() {
+		published, ok := unixOf(meta[FieldPublished])
+		if !ok {
+			return false
+		}
+		if !f.After.IsZero() && published < f.After.Unix() {
+			return false
+		}
+		if !f.Before.IsZero() && published >= f.Before.Unix() {
+			return false
+		}
+	}
+	return true
+}
+
+func (f Filter) normalizedDomains() []string {
+	domains := make([]string, 0, len(f.Domains))
+	for _, d := range f.Domains {
+		domains = append(domains, strings.TrimPrefix(strings.ToLower(d), "www."))
+	}
+	return domains
+}
+
+func (f Filter) normalizedLangs() []string {
+	langs := make([]string, 0, len(f.Langs))
+	for _, l := range f.Langs {
+		langs = append(langs, normalizeLang(l))
+	}
+	return langs
+}
+
+func anyIn(values []string, wanted []string) bool {
+	return slices.ContainsFunc(values, func(v string) bool {
+		return slices.Contains(wanted, v)
+	})
+}
+
+// StringsOf reads a string or list of strings metadata value
+func StringsOf(v any) []string {
+	switch v := v.(type) {
+	case string:
+		return []string{v}
+	case []string:
+		return v
+	case []any:
+		var out []string
+		for _, e := range v {
+			if s, ok := e.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+// unixOf reads a numeric metadata value, which is a float64 once it has
+// round-tripped through JSON or protobuf
+func unixOf(v any) (int64, bool) {
+	switch v := v.(type) {
+	case int64:
+		return v, true
+	case int:
+		return int64(v), true
+	case float64:
+		return int64(v), true
+	}
+	return 0, false
+}
+
+func toAny(values []string) []any {
+	out := make([]any, len(values))
+	for i, v := range values {
+		out[i] = v
+	}
+	return out
+}
@@ -0,0 +1,74 @@
+package vectorstorage_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/ary82/goseek/internal/vectorstorage"
+)
+
+func TestFilter_Match(t *testing.T) {
+	published := time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC)
+	meta := vectorstorage.RecordMeta("https://www.docs.python.org/3/library/asyncio.html", published, "en-US", "code", "google")
+	undated := vectorstorage.RecordMeta("https://example.com/", time.Time{}, "", "prose", "crawl")
+
+	tests := []struct {
+		name   string
+		filter vectorstorage.Filter
+		meta   map[string]any
+		want   bool
+	}{
+		{name: "test empty filter", meta: undated, want: true},
+		{name: "test exact domain", filter: vectorstorage.Filter{Domains: []string{"docs.python.org"}}, meta: meta, want: true},
+		{name: "test parent domain", filter: vectorstorage.Filter{Domains: []string{"python.org"}}, meta: meta, want: true},
+		{name: "test other domain", filter: vectorstorage.Filter{Domains: []string{"go.dev"}}, meta: meta, want: false},
+		{name: "test lang region", filter: vectorstorage.Filter{Langs: []string{"en"}}, meta: meta, want: true},
+		{name: "test content type", filter: vectorstorage.Filter{ContentTypes: []string{"forum", "code"}}, meta: meta, want: true},
+		{name: "test engine", filter: vectorstorage.Filter{Engines: []string{"google"}}, meta: undated, want: false},
+		{name: "test in date range", filter: vectorstorage.Filter{After: published.AddDate(0, -1, 0), Before: published.AddDate(0, 1, 0)}, meta: meta, want: true},
+		{name: "test before range", filter: vectorstorage.Filter{After: published.AddDate(0, 1, 0)}, meta: meta, want: false},
+		{name: "test undated", filter: vectorstorage.Filter{After: published}, meta: undated, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Match(tt.meta); got != tt.want {
+				t.Errorf("Match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilter_Pinecone(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter vectorstorage.Filter
+		want   string
+	}{
+		{name: "test empty filter", want: "null"},
+		{
+			name:   "test single clause",
+			filter: vectorstorage.Filter{Domains: []string{"www.Python.org"}},
+			want:   `{"domains":{"$in":["python.org"]}}`,
+		},
+		{
+			name: "test combined clauses",
+			filter: vectorstorage.Filter{
+				Langs: []string{"en-GB"},
+				After: time.Unix(1700000000, 0),
+			},
+			want: `{"$and":[{"lang":{"$in":["en"]}},{"published":{"$gte":1700000000}}]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := json.Marshal(tt.filter.Pinecone())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Pinecone() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
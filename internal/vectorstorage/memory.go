@@ -0,0 +1,148 @@
+package vectorstorage
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+
+	"github.com/ary82/goseek/internal/embed"
+	"github.com/pinecone-io/go-pinecone/v3/pinecone"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// MemoryStorage is a VectorStore kept in process memory, for running
+// without Pinecone. It takes and returns the same record types as
+// PineconeStorage, embeds the "text" field of records with an Embedder and
+// evaluates filters natively.
+type MemoryStorage struct {
+	embedder embed.Embedder
+
+	mu         sync.RWMutex
+	namespaces map[string]map[string]memoryRecord
+}
+
+type memoryRecord struct {
+	fields map[string]any
+	vector []float32
+}
+
+func NewMemoryStorage(e embed.Embedder) VectorStore {
+	return &MemoryStorage{
+		embedder:   e,
+		namespaces: make(map[string]map[string]memoryRecord),
+	}
+}
+
+func (ms *MemoryStorage) UpsertRecords(ctx context.Context, records any, ns string) error {
+	recs, ok := records.([]*pinecone.IntegratedRecord)
+	if !ok {
+		return fmt.Errorf("unsupported records type %T", records)
+	}
+
+	texts := make([]string, len(recs))
+	for i, r := range recs {
+		texts[i], _ = (*r)["text"].(string)
+	}
+	vectors, err := ms.embedder.Embed(ctx, texts)
+	if err != nil {
+		return fmt.Errorf("failed to embed records: %v", err)
+	}
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	if ms.namespaces[ns] == nil {
+		ms.namespaces[ns] = make(map[string]memoryRecord)
+	}
+	for i, r := range recs {
+		id, _ := (*r)["id"].(string)
+		if id == "" {
+			return fmt.Errorf("record %d has no id", i)
+		}
+		fields := make(map[string]any, len(*r))
+		for k, v := range *r {
+			if k != "id" {
+				fields[k] = v
+			}
+		}
+		ms.namespaces[ns][id] = memoryRecord{fields: fields, vector: vectors[i]}
+	}
+
+	log.Printf("upsert succeeded")
+	return nil
+}
+
+func (ms *MemoryStorage) SearchTopK(ctx context.Context, query string, k int, ns string, filter Filter) (any, error) {
+	vectors, err := ms.embedder.Embed(ctx, []string{query})
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed query: %v", err)
+	}
+
+	ms.mu.RLock()
+	var hits []pinecone.Hit
+	for id, r := range ms.namespaces[ns] {
+		if !filter.Match(r.fields) {
+			continue
+		}
+		hits = append(hits, pinecone.Hit{
+			Id:     id,
+			Score:  float32(embed.Cosine(vectors[0], r.vector)),
+			Fields: r.fields,
+		})
+	}
+	ms.mu.RUnlock()
+
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].Id < hits[j].Id
+	})
+
+	res := &pinecone.SearchRecordsResponse{}
+	res.Result.Hits = hits[:min(k, len(hits))]
+	log.Printf("vectorsearch succeeded with %v results", len(res.Result.Hits))
+	return res, nil
+}
+
+func (ms *MemoryStorage) FetchRecords(ctx context.Context, ids []string, ns string) (any, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	res := &pinecone.FetchVectorsResponse{
+		Vectors:   make(map[string]*pinecone.Vector),
+		Namespace: ns,
+	}
+	for _, id := range ids {
+		r, ok := ms.namespaces[ns][id]
+		if !ok {
+			continue
+		}
+		meta, err := structpb.NewStruct(protoFields(r.fields))
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert record %v: %v", id, err)
+		}
+		values := r.vector
+		res.Vectors[id] = &pinecone.Vector{Id: id, Values: &values, Metadata: meta}
+	}
+
+	log.Printf("fetch succeeded with %v of %v records", len(res.Vectors), len(ids))
+	return res, nil
+}
+
+// protoFields converts field values to the types structpb accepts
+func protoFields(fields map[string]any) map[string]any {
+	out := make(map[string]any, len(fields))
+	for k, v := range fields {
+		switch v := v.(type) {
+		case []string:
+			out[k] = toAny(v)
+		case int64:
+			out[k] = float64(v)
+		default:
+			out[k] = v
+		}
+	}
+	return out
+}
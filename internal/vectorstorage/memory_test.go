@@ -0,0 +1,82 @@
+package vectorstorage_test
+
+import (
+	"context"
+	"io"
+	"log"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ary82/goseek/internal/embed"
+	"github.com/ary82/goseek/internal/vectorstorage"
+	"github.com/pinecone-io/go-pinecone/v3/pinecone"
+)
+
+func TestMemoryStorage(t *testing.T) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	ctx := context.Background()
+	e, err := embed.NewHashEmbedder(256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ms := vectorstorage.NewMemoryStorage(e)
+
+	record := func(id string, link string, text string, published time.Time) *pinecone.IntegratedRecord {
+		r := pinecone.IntegratedRecord{"id": id, "text": text, "link": link}
+		for k, v := range vectorstorage.RecordMeta(link, published, "en", "prose", "google") {
+			r[k] = v
+		}
+		return &r
+	}
+	records := []*pinecone.IntegratedRecord{
+		record("py", "https://docs.python.org/3/", "asyncio runs coroutines on an event loop", time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)),
+		record("blog", "https://blog.example.com/", "asyncio event loop tips and coroutines", time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)),
+		record("cats", "https://cats.example.com/", "cats sleep all day", time.Time{}),
+	}
+	if err := ms.UpsertRecords(ctx, records, "ns"); err != nil {
+		t.Fatalf("UpsertRecords() failed: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		filter vectorstorage.Filter
+		want   []string
+	}{
+		{name: "test no filter", want: []string{"py", "blog"}},
+		{name: "test domain", filter: vectorstorage.Filter{Domains: []string{"python.org"}}, want: []string{"py"}},
+		{name: "test date", filter: vectorstorage.Filter{Before: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}, want: []string{"blog"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := ms.SearchTopK(ctx, "asyncio runs coroutines on an event loop", 2, "ns", tt.filter)
+			if err != nil {
+				t.Fatalf("SearchTopK() failed: %v", err)
+			}
+			hits := res.(*pinecone.SearchRecordsResponse).Result.Hits
+			var got []string
+			for _, h := range hits {
+				got = append(got, h.Id)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("SearchTopK() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("SearchTopK() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+
+	res, err := ms.FetchRecords(ctx, []string{"py", "missing"}, "ns")
+	if err != nil {
+		t.Fatalf("FetchRecords() failed: %v", err)
+	}
+	vectors := res.(*pinecone.FetchVectorsResponse).Vectors
+	if len(vectors) != 1 || vectors["py"].Metadata.AsMap()["link"] != "https://docs.python.org/3/" {
+		t.Errorf("FetchRecords() = %v, want only py with its fields", vectors)
+	}
+}
@@ -44,7 +44,7 @@ func (ps *PineconeStorage) UpsertRecords(ctx context.Context, records any, ns st
 	return nil
 }
 
-func (ps *PineconeStorage) SearchTopK(ctx context.Context, query string, k int, ns string) (any, error) {
+func (ps *PineconeStorage) SearchTopK(ctx context.Context, query string, k int, ns string, filter Filter) (any, error) {
 	idxConnection, err := ps.Pc.Index(pinecone.NewIndexConnParams{Host: ps.Host, Namespace: ns})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create IndexConnection for Host: %v: %v", ps.Host, err)
@@ -52,12 +52,13 @@ func (ps *PineconeStorage) SearchTopK(ctx context.Context, query string, k int,
 
 	res, err := idxConnection.SearchRecords(ctx, &pinecone.SearchRecordsRequest{
 		Query: pinecone.SearchRecordsQuery{
-			TopK: int32(k),
+			TopK:   int32(k),
+			Filter: filter.Pinecone(),
 			Inputs: &map[string]any{
 				"text": query,
 			},
 		},
-		Fields: &[]string{"text", "link"},
+		Fields: &[]string{"text", "link", "sources", "title", "weight", "parent", FieldContentType},
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to search records: %v", err)
@@ -66,3 +67,18 @@ func (ps *PineconeStorage) SearchTopK(ctx context.Context, query string, k int,
 	log.Printf("vectorsearch succeeded with %v results", len(res.Result.Hits))
 	return res, err
 }
+
+func (ps *PineconeStorage) FetchRecords(ctx context.Context, ids []string, ns string) (any, error) {
+	idxConnection, err := ps.Pc.Index(pinecone.NewIndexConnParams{Host: ps.Host, Namespace: ns})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create IndexConnection for Host: %v: %v", ps.Host, err)
+	}
+
+	res, err := idxConnection.FetchVectors(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch records: %v", err)
+	}
+
+	log.Printf("fetch succeeded with %v of %v records", len(res.Vectors), len(ids))
+	return res, nil
+}
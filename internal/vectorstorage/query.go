@@ -0,0 +1,129 @@
+package vectorstorage
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ary82/goseek/internal/chunk"
+)
+
+// ParseFilter pulls search operators out of a query and returns the rest of
+// the query and the filter they describe. Supported operators:
+//
+//	site:docs.python.org    only that domain and its subdomains
+//	after:2024-01-01        published on or after a date (also 2024 or 2024-01)
+//	before:2025             published before a date
+//	last:year               published within the last day, week, month or
+//	                        year, or e.g. last:30d, last:6m, last:2y
+//	lang:en                 in a language
+//	type:code               of a content type: prose, code or forum
+//	engine:google           found by a source: google or crawl
+//
+// Repeating an operator matches any of its values. Unknown operators are
+// kept in the query.
+func ParseFilter(query string, now time.Time) (string, Filter, error) {
+	var (
+		f    Filter
+		rest []string
+	)
+	for _, word := range strings.Fields(query) {
+		op, value, ok := strings.Cut(word, ":")
+		if !ok || value == "" {
+			rest = append(rest, word)
+			continue
+		}
+
+		switch strings.ToLower(op) {
+		case "site":
+			f.Domains = append(f.Domains, strings.ToLower(value))
+		case "lang":
+			f.Langs = append(f.Langs, strings.ToLower(value))
+		case "type":
+			v, err := oneOf("content type", value, chunk.ContentProse, chunk.ContentCode, chunk.ContentForum)
+			if err != nil {
+				return "", Filter{}, err
+			}
+			f.ContentTypes = append(f.ContentTypes, v)
+		case "engine":
+			v, err := oneOf("engine", value, EngineGoogle, EngineCrawl)
+			if err != nil {
+				return "", Filter{}, err
+			}
+			f.Engines = append(f.Engines, v)
+		case "after":
+			t, err := parseDate(value)
+			if err != nil {
+				return "", Filter{}, err
+			}
+			f.After = t
+		case "before":
+			t, err := parseDate(value)
+			if err != nil {
+				return "", Filter{}, err
+			}
+			f.Before = t
+		case "last":
+			since, err := parseLast(value, now)
+			if err != nil {
+				return "", Filter{}, err
+			}
+			f.After = since
+		default:
+			rest = append(rest, word)
+		}
+	}
+	return strings.Join(rest, " "), f, nil
+}
+
+// oneOf returns value in lower case if it is one of the known values
+func oneOf(what string, value string, known ...string) (string, error) {
+	v := strings.ToLower(value)
+	if !slices.Contains(known, v) {
+		return "", fmt.Errorf("invalid %s %q, want %s", what, value, strings.Join(known, ", "))
+	}
+	return v, nil
+}
+
+// parseDate parses a year, a month or a day and returns its start
+func parseDate(s string) (time.Time, error) {
+	for _, layout := range []string{"2006-01-02", "2006-01", "2006"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q, want YYYY, YYYY-MM or YYYY-MM-DD", s)
+}
+
+var lastPeriod = regexp.MustCompile(`^(\d+)([dwmy])$`)
+
+// parseLast returns the start of a period ending now
+func parseLast(s string, now time.Time) (time.Time, error) {
+	switch strings.ToLower(s) {
+	case "day":
+		s = "1d"
+	case "week":
+		s = "1w"
+	case "month":
+		s = "1m"
+	case "year":
+		s = "1y"
+	}
+	m := lastPeriod.FindStringSubmatch(strings.ToLower(s))
+	if m == nil {
+		return time.Time{}, fmt.Errorf("invalid period %q, want day, week, month, year or e.g. 30d, 6m, 2y", s)
+	}
+	n, _ := strconv.Atoi(m[1])
+	switch m[2] {
+	case "d":
+		return now.AddDate(0, 0, -n), nil
+	case "w":
+		return now.AddDate(0, 0, -7*n), nil
+	case "m":
+		return now.AddDate(0, -n, 0), nil
+	}
+	return now.AddDate(-n, 0, 0), nil
+}
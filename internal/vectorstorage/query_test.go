@@ -0,0 +1,85 @@
+package vectorstorage_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ary82/goseek/internal/vectorstorage"
+)
+
+func TestParseFilter(t *testing.T) {
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		query    string
+		wantText string
+		want     vectorstorage.Filter
+		wantErr  bool
+	}{
+		{
+			name:     "test plain query",
+			query:    "how to read a file",
+			wantText: "how to read a file",
+		},
+		{
+			name:     "test site and lang",
+			query:    "asyncio tutorial site:docs.python.org site:realpython.com lang:EN",
+			wantText: "asyncio tutorial",
+			want: vectorstorage.Filter{
+				Domains: []string{"docs.python.org", "realpython.com"},
+				Langs:   []string{"en"},
+			},
+		},
+		{
+			name:     "test date range",
+			query:    "after:2024-06 go release notes before:2025 type:prose engine:google",
+			wantText: "go release notes",
+			want: vectorstorage.Filter{
+				After:        time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC),
+				Before:       time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+				ContentTypes: []string{"prose"},
+				Engines:      []string{"google"},
+			},
+		},
+		{
+			name:     "test last year",
+			query:    "rust async last:year",
+			wantText: "rust async",
+			want:     vectorstorage.Filter{After: time.Date(2025, 3, 15, 12, 0, 0, 0, time.UTC)},
+		},
+		{
+			name:     "test last days",
+			query:    "outage last:10d",
+			wantText: "outage",
+			want:     vectorstorage.Filter{After: time.Date(2026, 3, 5, 12, 0, 0, 0, time.UTC)},
+		},
+		{
+			name:     "test unknown operator and url",
+			query:    "intitle:foo https://example.com",
+			wantText: "intitle:foo https://example.com",
+		},
+		{name: "test invalid date", query: "news after:yesterday", wantErr: true},
+		{name: "test invalid period", query: "news last:decade", wantErr: true},
+		{name: "test invalid content type", query: "news type:video", wantErr: true},
+		{name: "test invalid engine", query: "news engine:bing", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotText, got, gotErr := vectorstorage.ParseFilter(tt.query, now)
+			if (gotErr != nil) != tt.wantErr {
+				t.Fatalf("ParseFilter() error = %v, wantErr %v", gotErr, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if gotText != tt.wantText {
+				t.Errorf("ParseFilter() text = %q, want %q", gotText, tt.wantText)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseFilter() filter = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}